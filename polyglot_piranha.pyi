@@ -25,6 +25,66 @@ def execute_piranha(piranha_argument: PiranhaArguments) -> list[PiranhaOutputSum
     """
     ...
 
+def check_piranha(piranha_arguments: PiranhaArguments, path_to_expected: str) -> str:
+    """
+    Executes piranha for the given `piranha_arguments` without editing the code base, and compares its output
+    with the expected code base (ignoring whitespace)
+    Parameters
+    ------------
+        piranha_arguments: Piranha Arguments
+            Configurations for piranha
+        path_to_expected: str
+            Path to the expected code base (e.g. the `expected` folder of a golden corpus)
+    Returns
+    ------------
+    The unified diff from the expected code base to the output of Piranha, empty if they match
+    """
+    ...
+
+def plan_piranha(piranha_arguments: PiranhaArguments, path_to_plan: str) -> int:
+    """
+    Executes piranha for the given `piranha_arguments` without editing the code base, and writes the planned edits
+    to a Json file, so that they can be reviewed before being applied (see `apply_plan`)
+    Parameters
+    ------------
+        piranha_arguments: Piranha Arguments
+            Configurations for piranha
+        path_to_plan: str
+            Path to the Json file the edit plan is written to
+    Returns
+    ------------
+    The number of files the plan edits
+    """
+    ...
+
+def apply_plan(path_to_plan: str) -> list[str]:
+    """
+    Applies the edits of the plan (see `plan_piranha`) byte-for-byte, without running the rules again.
+    The plan is applied only if none of the files it edits changed since it was made
+    Parameters
+    ------------
+        path_to_plan: str
+            Path to the Json file of the edit plan
+    Returns
+    ------------
+    The paths of the files that changed since the plan was made, empty if the plan was applied
+    """
+    ...
+
+def apply_quick_fix_bundle(path_to_bundle: str) -> list[str]:
+    """
+    Applies the fixes of the quick-fix bundle (see `path_to_quick_fix_bundle`), without running the rules again.
+    The bundle is applied only if none of the files it fixes changed since the analysis (as per their checksum)
+    Parameters
+    ------------
+        path_to_bundle: str
+            Path to the Json file of the quick-fix bundle
+    Returns
+    ------------
+    The paths of the files that changed since the analysis, empty if the bundle was applied
+    """
+    ...
+
 class PiranhaArguments:
     """
     A class to capture Piranha's configurations
@@ -48,7 +108,41 @@ class PiranhaArguments:
         global_tag_prefix: Optional[str] = 'GLOBAL_TAG',
         delete_file_if_empty: Optional[bool] = None,
         path_to_output: Optional[str] = None,
-        allow_dirty_ast: Optional[bool] = None
+        allow_dirty_ast: Optional[bool] = None,
+        file_size_threshold: Optional[int] = None,
+        force_large_files: Optional[bool] = None,
+        path_to_junit_report: Optional[str] = None,
+        disabled_builtin_rules: Optional[List[str]] = None,
+        path_to_patch: Optional[str] = None,
+        path_to_run_report: Optional[str] = None,
+        path_to_package_heatmap: Optional[str] = None,
+        comment_out_deletions: Optional[List[str]] = None,
+        path_to_sarif_report: Optional[str] = None,
+        lint_uncleanable_patterns: Optional[bool] = None,
+        path_to_corpus: Optional[str] = None,
+        error_result_handling: Optional[str] = None,
+        keep_flag_calls: Optional[bool] = None,
+        flag_call_replacement: Optional[str] = None,
+        dry_run_rules: Optional[List[str]] = None,
+        dry_run_flags: Optional[List[str]] = None,
+        dry_run_paths: Optional[List[str]] = None,
+        removed_flags: Optional[List[str]] = None,
+        path_to_codeowners: Optional[str] = None,
+        patch_path_prefixes: Optional[List[str]] = None,
+        provenance_comment: Optional[str] = None,
+        strip_provenance_comments: Optional[bool] = None,
+        deleted_branch_replacement: Optional[str] = None,
+        max_nesting_depth: Optional[int] = None,
+        renames: Optional[List[str]] = None,
+        path_to_flag_report: Optional[str] = None,
+        strict: Optional[bool] = None,
+        deadline: Optional[int] = None,
+        path_to_checkpoint: Optional[str] = None,
+        path_to_config_values: Optional[str] = None,
+        path_to_quick_fix_bundle: Optional[str] = None,
+        scan_flag_remnants: Optional[bool] = None,
+        ok_result_handling: Optional[str] = None,
+        unsupported_syntax: Optional[List[str]] = None
     ):
         """
         Constructs `PiranhaArguments`
@@ -73,6 +167,40 @@ class PiranhaArguments:
                  delete_file_if_empty (bool): User option that determines whether an empty file will be deleted
                  path_to_output (str): Path to the output json file
                  allow_dirty_ast (bool): Allows syntax errors in the input source code 
+                 file_size_threshold (int): Files larger than this threshold (in bytes) are reported but not edited
+                 force_large_files (bool): Edits the files larger than `file_size_threshold` too
+                 path_to_junit_report (str): Path to the JUnit XML report, where each analyzed file is a test case
+                 disabled_builtin_rules (list[str]): Names of the built-in cleanup rules (or groups of rules) to disable
+                 path_to_patch (str): Path to the patch file (unified diff) of the edits performed by Piranha
+                 path_to_run_report (str): Path to the run report, that reports whether the run was complete or partial (i.e. interrupted by an internal error)
+                 path_to_package_heatmap (str): Path to the package heatmap, that ranks the packages by the number of matches and rewrites
+                 comment_out_deletions (list[str]): Names of the rules (or groups of rules) whose deletions are commented out (with a `piranha:commented-out` marker) instead
+                 path_to_sarif_report (str): Path to the SARIF report of the matches (e.g. of the usages that cannot be cleaned up)
+                 lint_uncleanable_patterns (bool): Detects the usages of the flag API that cannot be cleaned up (e.g. dynamic flag names). The flag API is provided as the substitution `flag_api`
+                 path_to_corpus (str): Directory of the regression corpus, where the (input, expected) pairs of the edited files are recorded as a new case (in the layout of `test-resources`)
+                 error_result_handling (str): How the error result of the flag APIs returning `(bool, error)` is handled : `assume_nil` (default), `preserve_call` or `keep_handling`
+                 keep_flag_calls (bool): Preserves the flag API calls as standalone statements (e.g. since they record exposure events), when the conditionals are replaced with the treated branch
+                 flag_call_replacement (str): The no-op call replacing the flag API calls preserved with `keep_flag_calls` (e.g. `exp.RecordExposure(@arg_str_literal)`), its tags are filled from the rule finding the stale flag
+                 dry_run_rules (List[str]): In dry-run mode, only prints the diffs of the files rewritten by these rules
+                 dry_run_flags (List[str]): In dry-run mode, only prints the diffs of the files where these flags were found
+                 dry_run_paths (List[str]): In dry-run mode, only prints the diffs of the files matching these paths (as glob patterns)
+                 removed_flags (List[str]): The names of the supposedly removed flags, whose remaining usages are reported
+                 path_to_codeowners (str): Path to a CODEOWNERS file, along which the patch is split (one patch file per owner)
+                 patch_path_prefixes (List[str]): Path prefixes along which the patch is split (one patch file per prefix)
+                 provenance_comment (str): The flag named in the provenance comment (e.g. `// cleaned: staleFlag (piranha)`) annotating each surviving rewritten region
+                 strip_provenance_comments (bool): Strips the provenance comments left by a previous run with `provenance_comment`
+                 deleted_branch_replacement (str): The statement replacing the deleted (untreated) branch of the conditionals (e.g. `metrics.Inc("fallback")`), its tags are filled from the rule finding the stale flag
+                 max_nesting_depth (int): The files whose syntax tree is nested deeper than this (e.g. generated code) are reported but not edited (default: 1000)
+                 renames (List[str]): The identifiers to rename after the cleanup (as `old=new` pairs)
+                 path_to_flag_report (str): Path to the flag report json file, that groups the edits by flag, then by package and file
+                 strict (bool): Fails the whole run (without editing the code base) if a usage could not be cleaned up, listing the blockers
+                 deadline (int): The time budget of the run (in seconds, 0 for none), after which the remaining files are written to the checkpoint
+                 path_to_checkpoint (str): Path to the checkpoint json file, written when the `deadline` is reached and resumed from by a later run
+                 path_to_config_values (str): Path to the toml file of the known config values, folded along with the flags they are compared with
+                 path_to_quick_fix_bundle (str): Path to the quick-fix bundle json file, i.e. the edits as machine-applicable fixes along with the checksum of each file
+                 scan_flag_remnants (bool): Reports the mentions of the `removed_flags` left in the comments, the string literals and the struct tags
+                 ok_result_handling (str): How the `ok` result of the flag APIs returning `(value, ok)` is handled : `assume_ok` or `preserve_check`
+                 unsupported_syntax (List[str]): The constructs the grammar does not support (as regexes), the functions using them are skipped
         """
         ...
 
@@ -165,11 +293,14 @@ class Constraint:
     "Scope in which the constraint query has to be applied"
     queries: list[TSQuery]
     "The Tree-sitter queries that need to be applied in the `matcher` scope"
+    package_queries: list[TSQuery]
+    "The Tree-sitter queries that need to be applied to the other files of the package (i.e. of the same directory)"
 
     def __init__(
         self,
         matcher: str,
-        queries: list[str] = []
+        queries: list[str] = [],
+        package_queries: list[str] = []
     ):
         """
         Constructs `Constraint`
@@ -180,6 +311,8 @@ class Constraint:
                 Scope in which the constraint query has to be applied
             queries: list[str]
                  The Tree-sitter queries that need to be applied in the `matcher` scope
+            package_queries: list[str]
+                 The Tree-sitter queries that need to be applied to the other files of the package (i.e. of the same directory)
         """
         ...
 
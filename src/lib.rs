@@ -13,23 +13,62 @@ Copyright (c) 2023 Uber Technologies, Inc.
 
 #![allow(deprecated)] // This prevents cargo clippy throwing warning for deprecated use.
 use models::{
-  constraint::Constraint, edit::Edit, matches::Match, outgoing_edges::OutgoingEdges,
-  piranha_arguments::PiranhaArguments, piranha_output::PiranhaOutputSummary, rule::Rule,
-  rule_graph::RuleGraph, source_code_unit::SourceCodeUnit,
+  constraint::Constraint,
+  edit::Edit,
+  matches::Match,
+  outgoing_edges::OutgoingEdges,
+  piranha_arguments::PiranhaArguments,
+  piranha_output::PiranhaOutputSummary,
+  rule::{InstantiatedRule, Rule},
+  rule_graph::RuleGraph,
+  source_code_unit::SourceCodeUnit,
 };
 
 pub mod models;
+mod reports;
 #[cfg(test)]
 mod tests;
 pub mod utilities;
 
-use std::{collections::HashMap, fs::File, io::Write, path::PathBuf};
+use std::{
+  any::Any,
+  collections::{HashMap, HashSet},
+  fs::{self, File},
+  io::Write,
+  panic::{self, AssertUnwindSafe},
+  path::{Path, PathBuf},
+  time::{Duration, Instant},
+};
 
 use itertools::Itertools;
-use log::{debug, info};
+use log::{debug, error, info, warn};
 use tree_sitter::Parser;
 
-use crate::models::rule_store::RuleStore;
+use crate::models::{
+  default_configs::{
+    DISABLE_FILE_DIRECTIVE, MAX_CASCADE_DEPTH, RENAME_COLLISION_GROUP, RENAME_DECLARATION_GROUP,
+    RENAME_FROM, RENAME_GROUP, RENAME_IMPORTED_MEMBER_GROUP, RENAME_IMPORT_GROUP,
+    RENAME_MEMBER_DECLARATION_GROUP, RENAME_MEMBER_GROUP, RENAME_PACKAGE,
+    RENAME_PACKAGE_NAME_GROUP, RENAME_QUALIFIED_GROUP, RENAME_TO,
+  },
+  rule_store::RuleStore,
+};
+use crate::reports::{
+  check::get_mismatches,
+  checkpoint::{Checkpoint, CheckpointedRule},
+  corpus::write_corpus_case,
+  flag_report::{get_flag_cleanups, write_flag_report, FlagCleanup, FlagEdit},
+  heatmap::{get_package_heatmap, write_package_heatmap},
+  junit::{write_junit_report, JUnitStatus, JUnitTestCase},
+  manifest::RunManifest,
+  ownership::{longest_path_prefix, split_by_owner, write_patches_by_owner, CodeOwners},
+  patch::{to_patch, write_patch, FilePatch},
+  plan::EditPlan,
+  quick_fix::QuickFixBundle,
+  run_report::{write_run_report, RenamedIdentifier, RunReport, UnsupportedFunction},
+  sarif::{write_sarif_report, SarifResult},
+};
+use crate::utilities::tree_sitter_utilities::get_max_depth;
 
 use pyo3::prelude::{pyfunction, pymodule, wrap_pyfunction, PyModule, PyResult, Python};
 use tempdir::TempDir;
@@ -38,6 +77,10 @@ use tempdir::TempDir;
 fn polyglot_piranha(_py: Python<'_>, m: &PyModule) -> PyResult<()> {
   pyo3_log::init();
   m.add_function(wrap_pyfunction!(execute_piranha, m)?)?;
+  m.add_function(wrap_pyfunction!(check_piranha, m)?)?;
+  m.add_function(wrap_pyfunction!(plan_piranha, m)?)?;
+  m.add_function(wrap_pyfunction!(apply_plan, m)?)?;
+  m.add_function(wrap_pyfunction!(apply_quick_fix_bundle, m)?)?;
   m.add_class::<PiranhaArguments>()?;
   m.add_class::<PiranhaOutputSummary>()?;
   m.add_class::<Edit>()?;
@@ -56,6 +99,16 @@ fn polyglot_piranha(_py: Python<'_>, m: &PyModule) -> PyResult<()> {
 ///
 /// Returns Piranha Output Summary for each file touched or analyzed by Piranha.
 /// For each file, it reports its content after the rewrite, the list of matches and the list of rewrites.
+///
+/// If Piranha is interrupted by an internal error, the run is partial : the edits computed so far
+/// are not persisted in place, but are written to the patch (and the run report lists the remaining files),
+/// before panicking with the error.
+///
+/// Each edited file is re-parsed at the end of the cleanup. If the edits produced syntax errors, they are rolled back
+/// (i.e. the file is left untouched), and Piranha panics once the other files are persisted and the reports are written.
+///
+/// With `strict`, the code base is not edited at all if a usage could not be cleaned up (see `Piranha::get_strict_blockers`),
+/// and Piranha panics with the list of the blockers once the reports are written.
 #[pyfunction]
 pub fn execute_piranha(piranha_arguments: &PiranhaArguments) -> Vec<PiranhaOutputSummary> {
   info!("Executing Polyglot Piranha !!!");
@@ -63,11 +116,94 @@ pub fn execute_piranha(piranha_arguments: &PiranhaArguments) -> Vec<PiranhaOutpu
   let mut piranha = Piranha::new(piranha_arguments);
   piranha.perform_cleanup();
 
-  let source_code_units = piranha.get_updated_files();
+  let manifest = RunManifest::new(piranha_arguments);
+  let run_report = piranha.get_run_report().with_manifest(manifest.clone());
+  let strict_blockers = if *piranha_arguments.strict() {
+    piranha.get_strict_blockers()
+  } else {
+    vec![]
+  };
+  let persisted = !run_report.is_partial() && strict_blockers.is_empty();
+  if persisted {
+    for scu in piranha.get_updated_files().iter() {
+      scu.persist();
+    }
+  }
 
-  for scu in source_code_units.iter() {
-    scu.persist();
+  if let Some(path) = piranha_arguments.path_to_patch() {
+    write_patches(piranha_arguments, &piranha.get_file_patches(), path);
+  }
+  if let Some(path) = piranha_arguments.path_to_quick_fix_bundle() {
+    let bundle = QuickFixBundle::new(
+      get_canonical_path(piranha_arguments.path_to_codebase()),
+      &piranha.get_file_patches(),
+    )
+    .with_manifest(manifest.clone());
+    bundle.write(path);
+  }
+  if let Some(path) = piranha_arguments.path_to_junit_report() {
+    write_junit_report(&piranha.get_junit_test_cases(), &manifest, path);
+  }
+  if let Some(path) = piranha_arguments.path_to_run_report() {
+    write_run_report(&run_report, path);
+  }
+  if let Some(path) = piranha_arguments.path_to_package_heatmap() {
+    let heatmap = get_package_heatmap(&piranha.get_file_usages());
+    write_package_heatmap(&heatmap, &manifest, path);
+  }
+  if let Some(path) = piranha_arguments.path_to_sarif_report() {
+    write_sarif_report(&piranha.get_sarif_results(), &manifest, path);
   }
+  if let Some(path) = piranha_arguments.path_to_flag_report() {
+    write_flag_report(&piranha.get_flag_cleanups(), &manifest, path);
+  }
+  if let Some(path) = piranha_arguments.path_to_checkpoint() {
+    // The checkpoint is only updated once the processed files are persisted
+    match &piranha.checkpoint {
+      Some(checkpoint) if persisted => {
+        checkpoint
+          .clone()
+          .with_manifest(manifest.clone())
+          .write(path);
+        #[rustfmt::skip]
+        warn!("Wrote the checkpoint {}, run Piranha again to process the remaining files.", path);
+      }
+      None if persisted && Path::new(path).exists() => {
+        // The resumed run is complete
+        _ = fs::remove_file(path);
+      }
+      _ => {}
+    }
+  }
+  if let Some(path) = piranha_arguments.path_to_corpus() {
+    // The edits of a partial (or checkpointed) run are incomplete, hence they are not recorded
+    if !run_report.is_partial() && !run_report.is_checkpointed() {
+      write_corpus_case(
+        &piranha.get_file_patches(),
+        piranha_arguments.path_to_configurations(),
+        &piranha_arguments.input_substitutions(),
+        path,
+      );
+    }
+  }
+  if let Some(e) = run_report.error() {
+    #[rustfmt::skip]
+    panic!("Piranha run is partial, {} files remain to be processed. Failed with : {}", run_report.remaining_files().len(), e);
+  }
+  if !strict_blockers.is_empty() {
+    #[rustfmt::skip]
+    panic!("Piranha refused the partial cleanup (strict mode), since some usages could not be cleaned up :\n{}", strict_blockers.join("\n"));
+  }
+  if !piranha.rolled_back_files.is_empty() {
+    let rolled_back_files = piranha
+      .rolled_back_files
+      .keys()
+      .map(|path| relative_to_codebase(piranha_arguments, path))
+      .sorted()
+      .join(", ");
+    panic!("Piranha rolled back the edits that produced syntax errors in : {rolled_back_files}");
+  }
+
   let summaries = piranha
     .get_updated_files()
     .iter()
@@ -77,6 +213,298 @@ pub fn execute_piranha(piranha_arguments: &PiranhaArguments) -> Vec<PiranhaOutpu
   summaries
 }
 
+/// Executes piranha for the given `piranha_arguments` without editing the code base, and compares its output
+/// with the expected code base at `path_to_expected` (e.g. the `expected` folder of a golden corpus).
+/// The files are compared ignoring whitespace, as in the test corpora.
+///
+/// Returns the unified diff from the expected code base to the output of Piranha, empty if they match.
+#[pyfunction]
+pub fn check_piranha(piranha_arguments: &PiranhaArguments, path_to_expected: &str) -> String {
+  info!("Checking Polyglot Piranha against {path_to_expected} !!!");
+
+  let mut piranha = Piranha::new(piranha_arguments);
+  piranha.perform_cleanup();
+
+  if let Some(e) = piranha.get_run_report().error() {
+    panic!("Piranha check failed with : {e}");
+  }
+  let mismatches = get_mismatches(
+    &piranha.get_file_patches(),
+    piranha_arguments.path_to_codebase(),
+    path_to_expected,
+    piranha_arguments.language(),
+  );
+  for mismatch in &mismatches {
+    warn!("{} does not match the expected output", mismatch.path());
+  }
+  to_patch(&mismatches)
+}
+
+/// Executes piranha for the given `piranha_arguments` without editing the code base, and writes the planned edits
+/// to `path_to_plan` (a Json file), so that they can be reviewed before being applied (see `apply_plan`).
+///
+/// Returns the number of files the plan edits.
+#[pyfunction]
+pub fn plan_piranha(piranha_arguments: &PiranhaArguments, path_to_plan: &str) -> usize {
+  info!("Planning Polyglot Piranha !!!");
+
+  let mut piranha = Piranha::new(piranha_arguments);
+  piranha.perform_cleanup();
+
+  if let Some(e) = piranha.get_run_report().error() {
+    panic!("Piranha plan failed with : {e}");
+  }
+  // The plan may be applied from another directory
+  let path_to_codebase = get_canonical_path(piranha_arguments.path_to_codebase());
+  let plan = EditPlan::new(path_to_codebase, &piranha.get_file_patches())
+    .with_manifest(RunManifest::new(piranha_arguments));
+  plan.write(path_to_plan);
+  info!("Planned the edits of {} files", plan.files().len());
+  plan.files().len()
+}
+
+/// Applies the edits planned at `path_to_plan` (see `plan_piranha`) byte-for-byte, without running the rules again.
+/// The plan is applied only if none of the files it edits changed since it was made.
+///
+/// Returns the paths of the files that changed since the plan was made, empty if the plan was applied.
+#[pyfunction]
+pub fn apply_plan(path_to_plan: &str) -> Vec<String> {
+  info!("Applying the edit plan {path_to_plan} !!!");
+
+  let plan = EditPlan::read(path_to_plan);
+  let conflicts = plan.get_conflicts();
+  if conflicts.is_empty() {
+    plan.apply();
+  }
+  conflicts
+}
+
+/// Applies the fixes of the quick-fix bundle at `path_to_bundle` (see `path_to_quick_fix_bundle`), without running the rules again.
+/// The bundle is applied only if none of the files it fixes changed since the analysis (as per their checksum).
+///
+/// Returns the paths of the files that changed since the analysis, empty if the bundle was applied.
+#[pyfunction]
+pub fn apply_quick_fix_bundle(path_to_bundle: &str) -> Vec<String> {
+  info!("Applying the quick-fix bundle {path_to_bundle} !!!");
+
+  let bundle = QuickFixBundle::read(path_to_bundle);
+  let conflicts = bundle.get_conflicts();
+  if conflicts.is_empty() {
+    bundle.apply();
+  }
+  conflicts
+}
+
+/// Returns the canonical form of `path` (e.g. for the reports applied from another directory), `path` itself if it does not exist.
+fn get_canonical_path(path: &str) -> String {
+  fs::canonicalize(path)
+    .map(|p| p.display().to_string())
+    .unwrap_or_else(|_| path.to_string())
+}
+
+/// Writes the patch of the edits to `path_to_patch`.
+/// With `path_to_codeowners` (or `patch_path_prefixes`), the patch is split into one patch file per owner (or path prefix) instead.
+fn write_patches(
+  piranha_arguments: &PiranhaArguments, file_patches: &[FilePatch], path_to_patch: &String,
+) {
+  let prefixes = piranha_arguments.patch_path_prefixes();
+  if let Some(path_to_codeowners) = piranha_arguments.path_to_codeowners() {
+    let code_owners = CodeOwners::read(path_to_codeowners);
+    let file_patches_by_owner = split_by_owner(file_patches, |path| {
+      code_owners.owners_of(path).map(|owners| owners.join(" "))
+    });
+    write_patches_by_owner(&file_patches_by_owner, path_to_patch);
+  } else if !prefixes.is_empty() {
+    let file_patches_by_prefix = split_by_owner(file_patches, |path| {
+      longest_path_prefix(prefixes, path).cloned()
+    });
+    write_patches_by_owner(&file_patches_by_prefix, path_to_patch);
+  } else {
+    write_patch(file_patches, path_to_patch);
+  }
+}
+
+/// Renders the unified diff of the files edited by a dry run of `execute_piranha` (printed by the command line interface).
+/// The diffs can be narrowed to the files of interest with the filters of `piranha_arguments`, in which case a file is included only if
+/// * it was rewritten by one of the `dry_run_rules`,
+/// * one of the `dry_run_flags` was captured by a match (or rewrite) of a rule in the file (e.g. the flag name argument of the flag API), and
+/// * its path matches one of the `dry_run_paths` (as for `include`).
+///
+/// An empty filter includes all the files.
+pub fn get_dry_run_diff(
+  piranha_arguments: &PiranhaArguments, summaries: &[PiranhaOutputSummary],
+) -> String {
+  let file_patches = summaries
+    .iter()
+    .filter(|summary| is_selected_by_dry_run_filters(piranha_arguments, summary))
+    .map(|summary| {
+      let deleted = summary.content().is_empty() && *piranha_arguments.delete_file_if_empty();
+      FilePatch::new(
+        relative_to_codebase(piranha_arguments, summary.path()),
+        summary.original_content().to_string(),
+        (!deleted).then(|| summary.content().to_string()),
+      )
+    })
+    .collect_vec();
+  to_patch(&file_patches)
+}
+
+/// Lists the remaining usages of the `removed_flags` of `piranha_arguments` found by `execute_piranha`,
+/// sorted by file and position (e.g. `pkg/handler.go:12:6: removed flag "stale_flag" (removed_flag_api_call)`).
+/// The command line interface prints them, and exits with a nonzero status if there is any.
+pub fn get_removed_flag_usages(
+  piranha_arguments: &PiranhaArguments, summaries: &[PiranhaOutputSummary],
+) -> Vec<String> {
+  let enforcement_rules = piranha_arguments
+    .language()
+    .enforcement_rules()
+    .unwrap_or_default()
+    .rules
+    .into_iter()
+    .map(|r| r.name().to_string())
+    .collect::<HashSet<String>>();
+  summaries
+    .iter()
+    .flat_map(|summary| {
+      summary
+        .matches()
+        .iter()
+        .filter(|(rule_name, _)| enforcement_rules.contains(rule_name))
+        .map(|(rule_name, m)| {
+          (
+            relative_to_codebase(piranha_arguments, summary.path()),
+            rule_name,
+            m,
+          )
+        })
+    })
+    .sorted_by_key(|(path, _, m)| (path.clone(), m.range().start_byte))
+    .map(|(path, rule_name, m)| {
+      let start = m.range().start_point;
+      let flag_name = m
+        .matches()
+        .get("enforcement_flag_name")
+        .map(|f| f.trim_matches(['"', '`']).to_string())
+        .unwrap_or_default();
+      format!(
+        "{path}:{}:{}: removed flag \"{flag_name}\" ({rule_name})",
+        start.row + 1,
+        start.column + 1
+      )
+    })
+    .collect_vec()
+}
+
+/// Lists the mentions of the `removed_flags` of `piranha_arguments` left in the comments, the string literals and the struct tags
+/// (with `scan_flag_remnants`), sorted by file and position (e.g. `pkg/handler.go:12:6: remnant of the flag "stale_flag" in a comment (flag_remnant_comment)`).
+/// The command line interface prints them, they are not edited.
+pub fn get_flag_remnants(
+  piranha_arguments: &PiranhaArguments, summaries: &[PiranhaOutputSummary],
+) -> Vec<String> {
+  // The kind of remnant detected by each rule, a struct tag being a string literal too
+  let remnant_kinds = HashMap::from([
+    ("flag_remnant_comment", "comment"),
+    ("flag_remnant_struct_tag", "struct tag"),
+    ("flag_remnant_string_literal", "string literal"),
+  ]);
+  summaries
+    .iter()
+    .flat_map(|summary| {
+      summary
+        .matches()
+        .iter()
+        .filter(|(rule_name, _)| remnant_kinds.contains_key(rule_name.as_str()))
+        .map(|(rule_name, m)| {
+          (
+            relative_to_codebase(piranha_arguments, summary.path()),
+            rule_name,
+            m,
+          )
+        })
+    })
+    .sorted_by_key(|(path, rule_name, m)| {
+      (
+        path.clone(),
+        m.range().start_byte,
+        rule_name.as_str() == "flag_remnant_string_literal",
+      )
+    })
+    // The string literals reported as struct tags are reported once
+    .dedup_by(|(a_path, _, a), (b_path, _, b)| a_path == b_path && a.range() == b.range())
+    .map(|(path, rule_name, m)| {
+      let start = m.range().start_point;
+      let flag_name = piranha_arguments
+        .removed_flags()
+        .iter()
+        .find(|f| m.matched_string().contains(f.as_str()))
+        .cloned()
+        .unwrap_or_default();
+      format!(
+        "{path}:{}:{}: remnant of the flag \"{flag_name}\" in a {} ({rule_name})",
+        start.row + 1,
+        start.column + 1,
+        remnant_kinds[rule_name.as_str()]
+      )
+    })
+    .collect_vec()
+}
+
+/// Returns `path` relative to the `path_to_codebase` (if possible).
+fn relative_to_codebase(piranha_arguments: &PiranhaArguments, path: impl AsRef<Path>) -> String {
+  let path = path.as_ref();
+  path
+    .strip_prefix(piranha_arguments.path_to_codebase())
+    .ok()
+    .filter(|p| !p.as_os_str().is_empty())
+    .unwrap_or(path)
+    .display()
+    .to_string()
+}
+
+/// Whether the paths `a` and `b` refer to the same file (or directory).
+fn is_same_path(a: &str, b: &str) -> bool {
+  match (fs::canonicalize(a), fs::canonicalize(b)) {
+    (Ok(a), Ok(b)) => a == b,
+    _ => a == b,
+  }
+}
+
+fn is_selected_by_dry_run_filters(
+  piranha_arguments: &PiranhaArguments, summary: &PiranhaOutputSummary,
+) -> bool {
+  let rules = piranha_arguments.dry_run_rules();
+  let flags = piranha_arguments.dry_run_flags();
+  let paths = piranha_arguments.dry_run_paths();
+
+  let rewritten_by_rule = || {
+    summary
+      .rewrites()
+      .iter()
+      .any(|edit| rules.contains(edit.matched_rule()))
+  };
+  let captures_flag = || {
+    summary
+      .rewrites()
+      .iter()
+      .map(|edit| edit.p_match())
+      .chain(summary.matches().iter().map(|(_, m)| m))
+      .flat_map(|m| m.matches().values())
+      .any(|captured| {
+        flags
+          .iter()
+          .any(|f| captured.trim_matches(['"', '\'', '`']) == f)
+      })
+  };
+  let matches_path = || {
+    paths
+      .iter()
+      .any(|p| p.matches_path(Path::new(summary.path())))
+  };
+  (rules.is_empty() || rewritten_by_rule())
+    && (flags.is_empty() || captures_flag())
+    && (paths.is_empty() || matches_path())
+}
+
 fn log_piranha_output_summaries(summaries: &Vec<PiranhaOutputSummary>) {
   let mut total_number_of_matches: usize = 0;
   let mut total_number_of_rewrites: usize = 0;
@@ -100,6 +528,22 @@ struct Piranha {
   rule_store: RuleStore,
   // Files updated by Piranha.
   relevant_files: HashMap<PathBuf, SourceCodeUnit>,
+  // Files above the `file_size_threshold`, that are reported but not edited.
+  large_files: HashSet<PathBuf>,
+  // Files nested deeper than `max_nesting_depth`, that are reported but not edited.
+  deeply_nested_files: HashSet<PathBuf>,
+  // Files whose edits were rolled back since they produced syntax errors (and the position of the first error).
+  rolled_back_files: HashMap<PathBuf, String>,
+  // The file (and the internal error) that interrupted the cleanup, if any.
+  failure: Option<(PathBuf, String)>,
+  // Files not processed because the cleanup was interrupted (including the failed file), or the `deadline` was reached.
+  remaining_files: Vec<PathBuf>,
+  // The work remaining when the `deadline` was reached, if it was.
+  checkpoint: Option<Checkpoint>,
+  // The renames refused since they would not compile (and why), see `perform_renames`.
+  refused_renames: HashMap<(String, String), String>,
+  // When the run started (the `deadline` is relative to it).
+  started: Instant,
   // Piranha Arguments
   piranha_arguments: PiranhaArguments,
 }
@@ -114,8 +558,338 @@ impl Piranha {
       .collect_vec()
   }
 
+  /// Returns the patches of the files updated by Piranha.
+  fn get_file_patches(&self) -> Vec<FilePatch> {
+    self
+      .get_updated_files()
+      .iter()
+      .map(|scu| {
+        let deleted = scu.code().is_empty() && *self.piranha_arguments.delete_file_if_empty();
+        FilePatch::new(
+          relative_to_codebase(&self.piranha_arguments, scu.path()),
+          scu.original_content().to_string(),
+          (!deleted).then(|| scu.code().to_string()),
+        )
+      })
+      .collect_vec()
+  }
+
+  /// Returns the number of matches and rewrites of each file touched or matched by Piranha.
+  fn get_file_usages(&self) -> Vec<(String, usize, usize)> {
+    self
+      .get_updated_files()
+      .iter()
+      .map(|scu| {
+        (
+          relative_to_codebase(&self.piranha_arguments, scu.path()),
+          scu.matches().len(),
+          scu.rewrites().len(),
+        )
+      })
+      .collect_vec()
+  }
+
+  /// Returns the occurrences of the "match-only" rules (e.g. the lint rules), sorted by file and position.
+  fn get_sarif_results(&self) -> Vec<SarifResult> {
+    self
+      .get_updated_files()
+      .iter()
+      .flat_map(|scu| {
+        let path = relative_to_codebase(&self.piranha_arguments, scu.path());
+        scu
+          .matches()
+          .iter()
+          .map(|(rule_name, m)| {
+            SarifResult::new(
+              rule_name.to_string(),
+              path.clone(),
+              m.matched_string().to_string(),
+              m.range(),
+            )
+          })
+          .collect_vec()
+      })
+      .sorted_by_key(|r| (r.path().clone(), r.range().start_byte))
+      .collect_vec()
+  }
+
+  /// Reports whether the cleanup was complete, interrupted by an internal error, or checkpointed at the `deadline`.
+  fn get_run_report(&self) -> RunReport {
+    let updated_files = self
+      .get_updated_files()
+      .iter()
+      .filter(|scu| !scu.rewrites().is_empty())
+      .map(|scu| relative_to_codebase(&self.piranha_arguments, scu.path()))
+      .sorted()
+      .collect_vec();
+    match &self.failure {
+      Some((path, e)) => RunReport::partial(
+        e.to_string(),
+        relative_to_codebase(&self.piranha_arguments, path),
+        updated_files,
+        self
+          .remaining_files
+          .iter()
+          .map(|p| relative_to_codebase(&self.piranha_arguments, p))
+          .collect_vec(),
+      ),
+      None if self.checkpoint.is_some() => RunReport::checkpointed(
+        updated_files,
+        self
+          .remaining_files
+          .iter()
+          .map(|p| relative_to_codebase(&self.piranha_arguments, p))
+          .collect_vec(),
+      ),
+      None => RunReport::complete(updated_files),
+    }
+    .with_renamed_identifiers(self.get_renamed_identifiers())
+    .with_skipped_functions(self.get_skipped_functions())
+  }
+
+  /// Lists the functions skipped (in each file) since they use a syntax the grammar does not support, sorted by file and position.
+  fn get_skipped_functions(&self) -> Vec<UnsupportedFunction> {
+    self
+      .relevant_files
+      .values()
+      .flat_map(|scu| {
+        scu.skipped_functions().iter().map(move |f| {
+          UnsupportedFunction::new(
+            relative_to_codebase(&self.piranha_arguments, scu.path()),
+            f.name().to_string(),
+            f.start_point().row + 1,
+            f.start_point().column + 1,
+            f.reason(),
+          )
+        })
+      })
+      .sorted_by(|a, b| (a.path(), a.line()).cmp(&(b.path(), b.line())))
+      .collect_vec()
+  }
+
+  /// Lists the occurrences of each of the `renames` renamed by Piranha, and the files they were renamed in (or why it was refused).
+  fn get_renamed_identifiers(&self) -> Vec<RenamedIdentifier> {
+    let rename_rules = self
+      .piranha_arguments
+      .language()
+      .rename_rules()
+      .map(|rules| {
+        rules
+          .rules
+          .iter()
+          .map(|r| r.name().to_string())
+          .collect_vec()
+      })
+      .unwrap_or_default();
+    let updated_files = self.get_updated_files();
+    self
+      .piranha_arguments
+      .get_renames()
+      .into_iter()
+      .map(|(from, to)| {
+        if let Some(refusal) = self
+          .refused_renames
+          .get(&(from.to_string(), to.to_string()))
+        {
+          return RenamedIdentifier::refused(from, to, refusal.to_string());
+        }
+        let occurrences_by_file = updated_files
+          .iter()
+          .map(|scu| {
+            let occurrences = scu
+              .rewrites()
+              .iter()
+              .filter(|r| rename_rules.contains(r.matched_rule()))
+              .filter(|r| *r.p_match().matched_string() == from)
+              .count();
+            (
+              relative_to_codebase(&self.piranha_arguments, scu.path()),
+              occurrences,
+            )
+          })
+          .filter(|(_, occurrences)| *occurrences > 0)
+          .sorted()
+          .collect_vec();
+        let occurrences = occurrences_by_file.iter().map(|(_, o)| o).sum();
+        let files = occurrences_by_file
+          .into_iter()
+          .map(|(f, _)| f)
+          .collect_vec();
+        RenamedIdentifier::new(from, to, occurrences, files)
+      })
+      .collect_vec()
+  }
+
+  /// Groups the rewrites by the flag of their cascade (see `SourceCodeUnit::rewrite_flags`), then by package and file.
+  fn get_flag_cleanups(&self) -> Vec<FlagCleanup> {
+    let edits = self
+      .get_updated_files()
+      .iter()
+      .flat_map(|scu| {
+        let path = relative_to_codebase(&self.piranha_arguments, scu.path());
+        scu
+          .rewrites()
+          .iter()
+          .zip(scu.rewrite_flags())
+          .map(|(edit, flag)| {
+            let edit = FlagEdit::new(
+              edit.matched_rule().to_string(),
+              edit.p_match().range().start_point.row + 1,
+              edit.p_match().matched_string().to_string(),
+              edit.replacement_string().to_string(),
+            );
+            (flag.clone(), path.clone(), edit)
+          })
+          .collect_vec()
+      })
+      .collect_vec();
+    get_flag_cleanups(edits)
+  }
+
+  /// Lists the usages that could not be cleaned up (sorted by file), i.e.
+  /// * the matches of the lint rules (e.g. a flag name built at runtime),
+  /// * the files opting out of the rewrites, that contain usages,
+  /// * the files that were not analyzed (larger than the file size threshold, or nested deeper than `max_nesting_depth`),
+  /// * the files whose edits were rolled back, or whose cleanup was cut off,
+  /// * the functions skipped since they use a syntax the grammar does not support.
+  fn get_strict_blockers(&self) -> Vec<String> {
+    let lint_rules = self
+      .piranha_arguments
+      .language()
+      .lint_rules()
+      .unwrap_or_default()
+      .rules
+      .into_iter()
+      .map(|r| r.name().to_string())
+      .collect::<HashSet<String>>();
+    let uncleanable_usages = self.relevant_files.values().flat_map(|scu| {
+      scu
+        .matches()
+        .iter()
+        .filter(|(rule_name, _)| lint_rules.contains(rule_name))
+        .map(|(rule_name, m)| {
+          let start = m.range().start_point;
+          let location = (scu.path().clone(), Some((start.row, start.column)));
+          let message = format!("uncleanable usage ({rule_name})");
+          (location, message)
+        })
+        .collect_vec()
+    });
+    let unedited_files = self.relevant_files.iter().filter_map(|(path, scu)| {
+      let message = if let Some(location) = self.rolled_back_files.get(path) {
+        format!("the edits produced a syntax error (at {location})")
+      } else if *scu.rewrites_disabled() && !scu.matches().is_empty() {
+        format!("the file opts out of the rewrites ({DISABLE_FILE_DIRECTIVE})")
+      } else if *scu.cascade_truncated() {
+        "the cleanup was cut off".to_string()
+      } else {
+        return None;
+      };
+      Some(((path.clone(), None), message))
+    });
+    let skipped_functions = self.relevant_files.values().flat_map(|scu| {
+      scu.skipped_functions().iter().map(move |f| {
+        let location = (
+          scu.path().clone(),
+          Some((f.start_point().row, f.start_point().column)),
+        );
+        let message = format!("skipped the function {} ({})", f.name(), f.reason());
+        (location, message)
+      })
+    });
+    let large_files = self.large_files.iter().map(|path| {
+      let message = "the file is larger than the file size threshold".to_string();
+      ((path.clone(), None), message)
+    });
+    let deeply_nested_files = self.deeply_nested_files.iter().map(|path| {
+      let message = "the file is nested deeper than the maximum nesting depth".to_string();
+      ((path.clone(), None), message)
+    });
+    uncleanable_usages
+      .chain(unedited_files)
+      .chain(skipped_functions)
+      .chain(large_files)
+      .chain(deeply_nested_files)
+      .sorted()
+      .map(|((path, position), message)| {
+        let path = relative_to_codebase(&self.piranha_arguments, &path);
+        match position {
+          Some((row, column)) => format!("{path}:{}:{}: {message}", row + 1, column + 1),
+          None => format!("{path}: {message}"),
+        }
+      })
+      .collect_vec()
+  }
+
+  /// Reports each file analyzed by Piranha as a JUnit test case, i.e.
+  /// * passed : the file was cleaned up (or matched)
+  /// * skipped : the file did not contain any usages
+  /// * failed : the file was not edited (since it is larger than the file size threshold, nested deeper than `max_nesting_depth`,
+  ///   it opts out of the rewrites, or its edits were rolled back), or its cleanup was cut off (or skips some functions)
+  fn get_junit_test_cases(&self) -> Vec<JUnitTestCase> {
+    let analyzed_files = self.relevant_files.iter().map(|(path, scu)| {
+      let status = if let Some(location) = self.rolled_back_files.get(path) {
+        JUnitStatus::Failed(format!(
+          "Not edited, since the edits produced a syntax error (at {location})"
+        ))
+      } else if !scu.skipped_functions().is_empty() {
+        // The skipped functions may contain usages
+        let skipped_functions = scu
+          .skipped_functions()
+          .iter()
+          .map(|f| format!("{} ({})", f.name(), f.reason()))
+          .join(", ");
+        JUnitStatus::Failed(format!(
+          "Partially cleaned up, since the grammar does not support the syntax of : {skipped_functions}"
+        ))
+      } else if scu.matches().is_empty() && scu.rewrites().is_empty() {
+        JUnitStatus::Skipped("No usages found".to_string())
+      } else if *scu.rewrites_disabled() {
+        JUnitStatus::Failed(format!(
+          "Not edited, since the file opts out of the rewrites ({DISABLE_FILE_DIRECTIVE})"
+        ))
+      } else if *scu.cascade_truncated() {
+        JUnitStatus::Failed(format!(
+          "Partially cleaned up, since the cascades of rules are nested deeper than {MAX_CASCADE_DEPTH} levels"
+        ))
+      } else {
+        JUnitStatus::Passed
+      };
+      JUnitTestCase::new(relative_to_codebase(&self.piranha_arguments, path), status)
+    });
+    let large_files = self.large_files.iter().map(|path| {
+      let message = format!(
+        "Not edited, since the file is larger than the file size threshold ({} bytes)",
+        self.piranha_arguments.file_size_threshold()
+      );
+      JUnitTestCase::new(
+        relative_to_codebase(&self.piranha_arguments, path),
+        JUnitStatus::Failed(message),
+      )
+    });
+    let deeply_nested_files = self.deeply_nested_files.iter().map(|path| {
+      let message = format!(
+        "Not edited, since the file is nested deeper than the maximum nesting depth ({})",
+        self.piranha_arguments.max_nesting_depth()
+      );
+      JUnitTestCase::new(
+        relative_to_codebase(&self.piranha_arguments, path),
+        JUnitStatus::Failed(message),
+      )
+    });
+    analyzed_files
+      .chain(large_files)
+      .chain(deeply_nested_files)
+      .sorted_by(|a, b| a.name().cmp(b.name()))
+      .collect_vec()
+  }
+
   /// Performs cleanup related to stale flags
   fn perform_cleanup(&mut self) {
+    let mut current_global_substitutions = self.piranha_arguments.input_substitutions();
+    // The first pass of a resumed run only processes the files remaining at the checkpoint
+    let mut resumed_files = self.resume_from_checkpoint(&mut current_global_substitutions);
+
     // Setup the parser for the specific language
     let mut parser = Parser::new();
     let piranha_args = &self.piranha_arguments;
@@ -133,63 +907,550 @@ impl Piranha {
       None
     };
 
-    let mut current_global_substitutions = piranha_args.input_substitutions();
-    // Keep looping until new `global` rules are added.
-    loop {
+    let mut processed_files = 0;
+    // Keep looping until new `global` (or `package`) rules are added.
+    'cleanup: loop {
       let current_rules = self.rule_store.global_rules().clone();
+      let current_package_rules = self.rule_store.package_rules().clone();
 
       debug!("\n # Global rules {}", current_rules.len());
       // Iterate over each file containing the usage of the feature flag API
+      let relevant_files = self
+        .rule_store
+        .get_relevant_files(
+          &path_to_codebase,
+          piranha_args.include(),
+          piranha_args.exclude(),
+        )
+        .into_iter()
+        .sorted_by(|(a, _), (b, _)| a.cmp(b))
+        .filter(|(p, _)| {
+          resumed_files.as_ref().map_or(true, |files| {
+            files.contains(&relative_to_codebase(&self.piranha_arguments, p))
+          })
+        })
+        .collect_vec();
+      let paths = relevant_files.iter().map(|(p, _)| p.clone()).collect_vec();
 
-      for (path, content) in self.rule_store.get_relevant_files(
-        &path_to_codebase,
-        piranha_args.include(),
-        piranha_args.exclude(),
-      ) {
-        // Get the `SourceCodeUnit` for the file `path` from the cache `relevant_files`.
-        // In case of miss, lazily insert a new `SourceCodeUnit`.
-        let source_code_unit = self
-          .relevant_files
-          .entry(path.to_path_buf())
-          .or_insert_with(|| {
-            SourceCodeUnit::new(
-              &mut parser,
-              content,
-              &current_global_substitutions,
-              path.as_path(),
-              piranha_args,
+      for (index, (path, content)) in relevant_files.into_iter().enumerate() {
+        // The file in flight is finished before checking the deadline, and each run processes at least one file
+        if self.is_past_deadline() && processed_files > 0 {
+          #[rustfmt::skip]
+          warn!("Reached the deadline ({} seconds), {} files remain to be processed.", piranha_args.deadline(), paths.len() - index);
+          self.remaining_files = paths[index..].to_vec();
+          self.checkpoint = Some(self.get_checkpoint(&current_global_substitutions));
+          break 'cleanup;
+        }
+        processed_files += 1;
+        if !*piranha_args.force_large_files()
+          && content.len() as u64 > *piranha_args.file_size_threshold()
+        {
+          if self.large_files.insert(path.to_path_buf()) {
+            #[rustfmt::skip]
+            warn!("Skipping {:?} ({} bytes) since it is larger than the file size threshold ({} bytes). Use `--force-large-files` to edit it.", path, content.len(), piranha_args.file_size_threshold());
+          }
+          continue;
+        }
+        if self.deeply_nested_files.contains(&path) {
+          continue;
+        }
+
+        // An internal error (i.e. a panic) while processing a file interrupts the cleanup,
+        // but the edits already computed for the other files are retained.
+        let result = panic::catch_unwind(AssertUnwindSafe(|| -> bool {
+          // Get the `SourceCodeUnit` for the file `path` from the cache `relevant_files`.
+          // In case of miss, lazily insert a new `SourceCodeUnit`.
+          let source_code_unit = self
+            .relevant_files
+            .entry(path.to_path_buf())
+            .or_insert_with(|| {
+              SourceCodeUnit::new(
+                &mut parser,
+                content,
+                &current_global_substitutions,
+                path.as_path(),
+                piranha_args,
+              )
+            });
+
+          // The matching and the cleanup of deeply nested trees risk the recursion limits
+          let depth = get_max_depth(source_code_unit.root_node());
+          if depth > *piranha_args.max_nesting_depth() as usize {
+            #[rustfmt::skip]
+            warn!("Skipping {:?} (nested {} levels deep) since it is nested deeper than the maximum nesting depth ({}).", path, depth, piranha_args.max_nesting_depth());
+            return false;
+          }
+
+          // Apply the rules in this `SourceCodeUnit`, i.e. the global rules and the rules of its package
+          let rules = current_rules
+            .iter()
+            .chain(
+              path
+                .parent()
+                .and_then(|p| current_package_rules.get(p))
+                .into_iter()
+                .flatten(),
             )
-          });
+            .cloned()
+            .collect_vec();
+          source_code_unit.apply_rules(&mut self.rule_store, &rules, &mut parser, None);
+          // The constraints checking the other files of the package (i.e. `package_queries`) consider its edits
+          self
+            .rule_store
+            .update_package_file(source_code_unit.path(), source_code_unit.code());
 
-        // Apply the rules in this `SourceCodeUnit`
-        source_code_unit.apply_rules(&mut self.rule_store, &current_rules, &mut parser, None);
+          // Add the substitutions for the global tags to the `current_global_substitutions`
+          current_global_substitutions.extend(source_code_unit.global_substitutions());
+          true
+        }));
 
-        // Add the substitutions for the global tags to the `current_global_substitutions`
-        current_global_substitutions.extend(source_code_unit.global_substitutions());
+        if let Ok(false) = result {
+          self.relevant_files.remove(&path);
+          self.deeply_nested_files.insert(path.to_path_buf());
+          continue;
+        }
+        if let Err(payload) = result {
+          // Discard the (incomplete) edits of the failed file
+          self.relevant_files.remove(&path);
+          let message = get_panic_message(payload);
+          error!(
+            "Interrupted the cleanup while processing {:?} : {}",
+            path, message
+          );
+          self.remaining_files = paths[index..].to_vec();
+          self.failure = Some((path, message));
+          break 'cleanup;
+        }
 
-        // Break when a new `global` rule is added
-        if self.rule_store.global_rules().len() > current_rules.len() {
-          debug!("Found a new global rule. Will start scanning all the files again.");
+        // Break when a new `global` (or `package`) rule is added
+        if self.has_new_rules(&current_rules, &current_package_rules) {
+          debug!("Found a new global (or package) rule. Will start scanning all the files again.");
           break;
         }
       }
-      // If no new `global_rules` were added, break.
-      if self.rule_store.global_rules().len() == current_rules.len() {
+      // If no new `global_rules` (or `package_rules`) were added, break.
+      if !self.has_new_rules(&current_rules, &current_package_rules) {
         break;
       }
+      // The new `global_rules` apply to the whole code base (and the new `package_rules` to their whole package)
+      resumed_files = None;
     }
+    // The renames apply to the code surviving the cleanup, hence they wait for the cleanup to complete
+    if self.failure.is_none() && self.checkpoint.is_none() {
+      self.perform_renames(&path_to_codebase, &mut parser);
+    } else if !self.piranha_arguments.renames().is_empty() {
+      warn!("Skipping the renames, since the cleanup is not complete.");
+    }
+    if let Some(flag) = self.piranha_arguments.provenance_comment() {
+      for source_code_unit in self.relevant_files.values_mut() {
+        if !source_code_unit.rewrites().is_empty() {
+          source_code_unit.annotate_provenance(flag, &mut parser);
+        }
+      }
+    }
+    self.roll_back_syntax_errors(&mut parser);
     // Delete the temp dir inside which the input code snippet was copied
     if let Some(t) = temp_dir {
       _ = t.close();
     }
   }
 
+  /// Checks if global or package rules were added since the `global_rules` and `package_rules` were collected.
+  fn has_new_rules(
+    &self, global_rules: &[InstantiatedRule],
+    package_rules: &HashMap<PathBuf, Vec<InstantiatedRule>>,
+  ) -> bool {
+    let number_of_package_rules: usize = package_rules.values().map(Vec::len).sum();
+    self.rule_store.global_rules().len() > global_rules.len()
+      || self.rule_store.number_of_package_rules() > number_of_package_rules
+  }
+
+  /// Renames the identifiers of the `renames` once the cleanup is complete (see `rename_rules.toml`).
+  /// A rename applies to the package declaring the old name and to the files importing this package,
+  /// the refused renames (see `resolve_rename`) are listed in the run report.
+  fn perform_renames(&mut self, path_to_codebase: &str, parser: &mut Parser) {
+    let renames = self.piranha_arguments.get_renames();
+    if renames.is_empty() {
+      return;
+    }
+    let rename_rules = match self.piranha_arguments.language().rename_rules() {
+      Some(rename_rules) => rename_rules.rules,
+      None => {
+        #[rustfmt::skip]
+        warn!("No rename rules for the language : {}", self.piranha_arguments.get_language());
+        return;
+      }
+    };
+    let piranha_args = self.piranha_arguments.clone();
+    // The files reported but not edited by the cleanup are not renamed either
+    let files = self
+      .rule_store
+      .get_files(
+        path_to_codebase,
+        piranha_args.include(),
+        piranha_args.exclude(),
+      )
+      .into_iter()
+      .filter(|(p, _)| !self.large_files.contains(p) && !self.deeply_nested_files.contains(p))
+      .collect::<HashMap<_, _>>();
+
+    for (old, new) in renames {
+      let substitutions = HashMap::from([
+        (RENAME_FROM.to_string(), old.to_string()),
+        (RENAME_TO.to_string(), new.to_string()),
+      ]);
+      // The files (e.g. with a syntax error) that cannot be analyzed prevent the rename
+      let resolution = panic::catch_unwind(AssertUnwindSafe(|| {
+        self.resolve_rename(
+          path_to_codebase,
+          &files,
+          &rename_rules,
+          &substitutions,
+          parser,
+        )
+      }))
+      .unwrap_or_else(|payload| Err(get_panic_message(payload)));
+      match resolution {
+        Ok(rules_by_file) => {
+          for (path, rules) in rules_by_file {
+            let code = self.get_current_code(&path, &files);
+            let source_code_unit = self
+              .relevant_files
+              .entry(path.to_path_buf())
+              .or_insert_with(|| {
+                SourceCodeUnit::new(parser, code, &HashMap::new(), &path, &piranha_args)
+              });
+            source_code_unit.apply_rules(&mut self.rule_store, &rules, parser, None);
+          }
+        }
+        Err(refusal) => {
+          warn!("Refused the rename {}={}, since {}", old, new, refusal);
+          self.refused_renames.insert((old, new), refusal);
+        }
+      }
+    }
+  }
+
+  /// Finds the package declaring the old name of the rename (`substitutions`), and returns the rename rules to apply to each file,
+  /// i.e. the files of the package and the files importing it.
+  /// Returns why the rename is refused if no package (or several packages) declare the old name, or if the package already uses the new name.
+  fn resolve_rename(
+    &mut self, path_to_codebase: &str, files: &HashMap<PathBuf, String>, rename_rules: &[Rule],
+    substitutions: &HashMap<String, String>, parser: &mut Parser,
+  ) -> Result<Vec<(PathBuf, Vec<InstantiatedRule>)>, String> {
+    let (old, new) = (&substitutions[RENAME_FROM], &substitutions[RENAME_TO]);
+    let mentioning_files = files
+      .keys()
+      .sorted()
+      .map(|path| (path.to_path_buf(), self.get_current_code(path, files)))
+      .filter(|(_, code)| code.contains(old.as_str()))
+      .collect_vec();
+
+    // The packages (i.e. directories) declaring the old name, along with the name of the package
+    let mut declaring_packages: HashMap<PathBuf, String> = HashMap::new();
+    let (mut is_declared, mut is_member_declared) = (false, false);
+    for (path, code) in &mentioning_files {
+      for (group, is_member) in [
+        (RENAME_DECLARATION_GROUP, false),
+        (RENAME_MEMBER_DECLARATION_GROUP, true),
+      ] {
+        let declarations =
+          self.get_rename_matches(path, code, rename_rules, group, substitutions, parser);
+        if declarations.is_empty() {
+          continue;
+        }
+        let package_name = self
+          .get_rename_matches(
+            path,
+            code,
+            rename_rules,
+            RENAME_PACKAGE_NAME_GROUP,
+            substitutions,
+            parser,
+          )
+          .first()
+          .map(|m| m.matches()["package_name"].to_string())
+          .unwrap_or_default();
+        let package = path.parent().map(Path::to_path_buf).unwrap_or_default();
+        declaring_packages.insert(package, package_name);
+        is_declared |= !is_member;
+        is_member_declared |= is_member;
+      }
+    }
+    let (package, package_name) = match declaring_packages.len() {
+      0 => return Err(format!("no package declares `{old}`")),
+      1 => declaring_packages.into_iter().next().unwrap(),
+      _ => {
+        let packages = declaring_packages
+          .keys()
+          .map(|p| relative_to_codebase(&self.piranha_arguments, p))
+          .sorted()
+          .join(", ");
+        return Err(format!("several packages declare `{old}` ({packages})"));
+      }
+    };
+
+    // The rename would redeclare (or shadow) the identifiers of the package already named like the new name
+    for path in files
+      .keys()
+      .filter(|p| p.parent() == Some(package.as_path()))
+      .sorted()
+    {
+      let code = self.get_current_code(path, files);
+      if code.contains(new.as_str())
+        && !self
+          .get_rename_matches(
+            path,
+            &code,
+            rename_rules,
+            RENAME_COLLISION_GROUP,
+            substitutions,
+            parser,
+          )
+          .is_empty()
+      {
+        return Err(format!(
+          "`{new}` is already used by {}",
+          relative_to_codebase(&self.piranha_arguments, path)
+        ));
+      }
+    }
+
+    // The import path of the package ends with its path relative to the code base (e.g. `example.com/shop/checkout`)
+    let relative_package = package
+      .strip_prefix(path_to_codebase)
+      .map(|p| {
+        p.components()
+          .map(|c| c.as_os_str().to_string_lossy())
+          .join("/")
+      })
+      .unwrap_or_default();
+    let get_rules = |group: &str, substitutions: &HashMap<String, String>| {
+      rename_rules
+        .iter()
+        .filter(|r| r.groups().contains(group))
+        .map(|r| InstantiatedRule::new(r, substitutions))
+        .collect_vec()
+    };
+    let mut rules_by_file = vec![];
+    for (path, code) in &mentioning_files {
+      let mut rules = vec![];
+      if path.parent() == Some(package.as_path()) {
+        if is_declared {
+          rules.extend(get_rules(RENAME_GROUP, substitutions));
+        }
+        if is_member_declared {
+          rules.extend(get_rules(RENAME_MEMBER_GROUP, substitutions));
+        }
+      }
+      // The files importing the package (including its external test package, e.g. `package checkout_test`)
+      let imports = self.get_rename_matches(
+        path,
+        code,
+        rename_rules,
+        RENAME_IMPORT_GROUP,
+        substitutions,
+        parser,
+      );
+      for import in imports {
+        let import_path = import.matches()["import_path"]
+          .trim_matches('"')
+          .to_string();
+        let is_package_import = !relative_package.is_empty()
+          && (import_path == relative_package
+            || import_path.ends_with(&format!("/{relative_package}")));
+        if !is_package_import {
+          continue;
+        }
+        if is_declared {
+          let mut qualified_substitutions = substitutions.clone();
+          qualified_substitutions.insert(
+            RENAME_PACKAGE.to_string(),
+            import
+              .matches()
+              .get("import_name")
+              .unwrap_or(&package_name)
+              .to_string(),
+          );
+          rules.extend(get_rules(RENAME_QUALIFIED_GROUP, &qualified_substitutions));
+        }
+        if is_member_declared && path.parent() != Some(package.as_path()) {
+          rules.extend(get_rules(RENAME_IMPORTED_MEMBER_GROUP, substitutions));
+        }
+      }
+      if !rules.is_empty() {
+        rules_by_file.push((path.to_path_buf(), rules));
+      }
+    }
+    Ok(rules_by_file)
+  }
+
+  /// Returns the matches of the rename rules of the `group` in the file `path`.
+  fn get_rename_matches(
+    &mut self, path: &Path, code: &str, rename_rules: &[Rule], group: &str,
+    substitutions: &HashMap<String, String>, parser: &mut Parser,
+  ) -> Vec<Match> {
+    let source_code_unit = SourceCodeUnit::new(
+      parser,
+      code.to_string(),
+      &HashMap::new(),
+      path,
+      &self.piranha_arguments,
+    );
+    rename_rules
+      .iter()
+      .filter(|r| r.groups().contains(group))
+      .flat_map(|r| {
+        source_code_unit.get_matches(
+          &InstantiatedRule::new(r, substitutions),
+          &mut self.rule_store,
+          source_code_unit.root_node(),
+          true,
+        )
+      })
+      .collect_vec()
+  }
+
+  /// Returns the content of the file `path`, including the edits of Piranha (if any).
+  fn get_current_code(&self, path: &Path, files: &HashMap<PathBuf, String>) -> String {
+    match self.relevant_files.get(path) {
+      Some(source_code_unit) => source_code_unit.code().to_string(),
+      None => files.get(path).cloned().unwrap_or_default(),
+    }
+  }
+
+  /// Whether the `deadline` (if any) was reached.
+  fn is_past_deadline(&self) -> bool {
+    let deadline = *self.piranha_arguments.deadline();
+    deadline > 0 && self.started.elapsed() >= Duration::from_secs(deadline)
+  }
+
+  /// Records the remaining files, along with the global (and package) rules and substitutions collected so far
+  /// (the seed rules are instantiated again by the resumed run).
+  fn get_checkpoint(&self, global_substitutions: &HashMap<String, String>) -> Checkpoint {
+    let global_rules = self
+      .rule_store
+      .global_rules()
+      .iter()
+      .filter(|r| !*r.rule().is_seed_rule())
+      .map(|r| CheckpointedRule::new(r.name(), r.substitutions().clone()))
+      .collect_vec();
+    // The packages are relative to the code base (i.e. the code base itself is the empty path)
+    let package_rules = self
+      .rule_store
+      .package_rules()
+      .iter()
+      .map(|(package, rules)| {
+        let package = package
+          .strip_prefix(self.piranha_arguments.path_to_codebase())
+          .unwrap_or(package);
+        let rules = rules
+          .iter()
+          .map(|r| CheckpointedRule::new(r.name(), r.substitutions().clone()))
+          .collect_vec();
+        (package.display().to_string(), rules)
+      })
+      .collect();
+    Checkpoint::new(
+      self.piranha_arguments.path_to_codebase().to_string(),
+      self
+        .remaining_files
+        .iter()
+        .map(|p| relative_to_codebase(&self.piranha_arguments, p))
+        .collect_vec(),
+      global_rules,
+      package_rules,
+      global_substitutions.clone(),
+    )
+  }
+
+  /// Restores the global rules and substitutions of the checkpoint `path_to_checkpoint` (if it exists),
+  /// and returns the files remaining at the checkpoint.
+  fn resume_from_checkpoint(
+    &mut self, global_substitutions: &mut HashMap<String, String>,
+  ) -> Option<HashSet<String>> {
+    let path_to_checkpoint = self.piranha_arguments.path_to_checkpoint().clone()?;
+    if !Path::new(&path_to_checkpoint).exists() {
+      return None;
+    }
+    let checkpoint = Checkpoint::read(&path_to_checkpoint);
+    let path_to_codebase = self.piranha_arguments.path_to_codebase();
+    if !is_same_path(checkpoint.path_to_codebase(), path_to_codebase) {
+      #[rustfmt::skip]
+      panic!("The checkpoint {} was written for the code base {}, not {}", path_to_checkpoint, checkpoint.path_to_codebase(), path_to_codebase);
+    }
+    let instantiate = |checkpointed_rule: &CheckpointedRule| {
+      let rule = self
+        .piranha_arguments
+        .rule_graph()
+        .rules()
+        .iter()
+        .find(|r| r.name() == checkpointed_rule.name())
+        .unwrap_or_else(|| {
+          #[rustfmt::skip]
+          panic!("The checkpoint {} refers to the rule {}, which is not loaded by this run", path_to_checkpoint, checkpointed_rule.name());
+        })
+        .clone();
+      InstantiatedRule::new(&rule, checkpointed_rule.substitutions())
+    };
+    let global_rules = checkpoint
+      .global_rules()
+      .iter()
+      .map(instantiate)
+      .collect_vec();
+    let package_rules = checkpoint
+      .package_rules()
+      .iter()
+      .flat_map(|(package, rules)| {
+        let package = Path::new(path_to_codebase).join(package);
+        rules.iter().map(move |r| (package.clone(), instantiate(r)))
+      })
+      .collect_vec();
+    for rule in &global_rules {
+      self.rule_store.add_to_global_rules(rule);
+    }
+    for (package, rule) in &package_rules {
+      self.rule_store.add_to_package_rules(package, rule);
+    }
+    global_substitutions.extend(checkpoint.global_substitutions().clone());
+    #[rustfmt::skip]
+    info!("Resuming from the checkpoint {}, {} files remain to be processed.", path_to_checkpoint, checkpoint.remaining_files().len());
+    Some(checkpoint.remaining_files().iter().cloned().collect())
+  }
+
+  /// Re-parses each edited file from scratch, and rolls back the edits of the files where they produced syntax errors,
+  /// so that a run never leaves syntactically incorrect code on disk.
+  fn roll_back_syntax_errors(&mut self, parser: &mut Parser) {
+    for (path, source_code_unit) in self.relevant_files.iter_mut() {
+      if source_code_unit.rewrites().is_empty() {
+        continue;
+      }
+      if let Some(position) = source_code_unit.find_introduced_syntax_error(parser) {
+        let location = format!("{}:{}", position.row + 1, position.column + 1);
+        error!(
+          "Rolled back the edits of {:?}, since they produced a syntax error at {}",
+          path, location
+        );
+        source_code_unit.roll_back(parser);
+        self.rolled_back_files.insert(path.to_path_buf(), location);
+      }
+    }
+  }
+
   /// Instantiate Flag-cleaner
   fn new(piranha_arguments: &PiranhaArguments) -> Self {
     let graph_rule_store = RuleStore::new(piranha_arguments);
     Self {
       rule_store: graph_rule_store,
       relevant_files: HashMap::new(),
+      large_files: HashSet::new(),
+      deeply_nested_files: HashSet::new(),
+      rolled_back_files: HashMap::new(),
+      failure: None,
+      remaining_files: vec![],
+      checkpoint: None,
+      refused_renames: HashMap::new(),
+      started: Instant::now(),
       piranha_arguments: piranha_arguments.clone(),
     }
   }
@@ -211,3 +1472,14 @@ impl Piranha {
     temp_dir
   }
 }
+
+/// Extracts the message of a panic (as caught by `panic::catch_unwind`).
+fn get_panic_message(payload: Box<dyn Any + Send>) -> String {
+  if let Some(message) = payload.downcast_ref::<&str>() {
+    return message.to_string();
+  }
+  if let Some(message) = payload.downcast_ref::<String>() {
+    return message.to_string();
+  }
+  "Unknown internal error".to_string()
+}
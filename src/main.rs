@@ -12,18 +12,88 @@
 */
 
 //! Defines the entry-point for Piranha.
-use std::{fs, time::Instant};
+use std::{env, fs, process, time::Instant};
 
+use clap::Parser;
 use log::{debug, info};
 use polyglot_piranha::{
-  execute_piranha, models::piranha_arguments::PiranhaArguments,
-  models::piranha_output::PiranhaOutputSummary,
+  apply_plan, apply_quick_fix_bundle, check_piranha, execute_piranha, get_dry_run_diff,
+  get_flag_remnants, get_removed_flag_usages, models::piranha_arguments::PiranhaArguments,
+  models::piranha_output::PiranhaOutputSummary, plan_piranha,
 };
 
+/// The subcommand comparing the output of Piranha with an expected code base (e.g. `piranha check --path-to-expected ...`).
+const CHECK_SUBCOMMAND: &str = "check";
+
+/// Runs the rules upon the code base (without editing it), and compares the output with the expected code base.
+/// Prints the diff and exits with a nonzero status if they do not match.
+#[derive(Debug, Parser)]
+#[clap(name = "Piranha check")]
+struct CheckArguments {
+  /// Path to the expected code base (e.g. the `expected` folder of a golden corpus)
+  #[clap(long, required = true)]
+  path_to_expected: String,
+  #[clap(flatten)]
+  piranha_arguments: PiranhaArguments,
+}
+
+/// The subcommand writing the edits of Piranha to a plan instead of the code base (e.g. `piranha plan --path-to-plan ...`).
+const PLAN_SUBCOMMAND: &str = "plan";
+
+/// The subcommand applying a (reviewed) plan to the code base (e.g. `piranha apply --path-to-plan ...`).
+const APPLY_SUBCOMMAND: &str = "apply";
+
+/// Runs the rules upon the code base (without editing it), and writes the planned edits to a Json file.
+#[derive(Debug, Parser)]
+#[clap(name = "Piranha plan")]
+struct PlanArguments {
+  /// Path to the Json file the edit plan is written to
+  #[clap(long, required = true)]
+  path_to_plan: String,
+  #[clap(flatten)]
+  piranha_arguments: PiranhaArguments,
+}
+
+/// Applies the edits of a plan byte-for-byte, without running the rules again.
+/// Exits with a nonzero status (without editing the code base) if a planned file changed since the plan was made.
+#[derive(Debug, Parser)]
+#[clap(name = "Piranha apply")]
+struct ApplyArguments {
+  /// Path to the Json file of the edit plan (see `piranha plan`)
+  #[clap(long, required = true)]
+  path_to_plan: String,
+}
+
+/// The subcommand applying a quick-fix bundle to the code base (e.g. `piranha apply-quick-fixes --path-to-quick-fix-bundle ...`).
+const APPLY_QUICK_FIXES_SUBCOMMAND: &str = "apply-quick-fixes";
+
+/// Applies the fixes of a quick-fix bundle (see `--path-to-quick-fix-bundle`), without running the rules again.
+/// Exits with a nonzero status (without editing the code base) if a fixed file changed since the analysis.
+#[derive(Debug, Parser)]
+#[clap(name = "Piranha apply-quick-fixes")]
+struct ApplyQuickFixesArguments {
+  /// Path to the Json file of the quick-fix bundle
+  #[clap(long, required = true)]
+  path_to_quick_fix_bundle: String,
+}
+
 fn main() {
   let now = Instant::now();
   env_logger::init();
 
+  if env::args().nth(1).as_deref() == Some(CHECK_SUBCOMMAND) {
+    check(CheckArguments::parse_from(env::args().skip(1)));
+  }
+  if env::args().nth(1).as_deref() == Some(PLAN_SUBCOMMAND) {
+    plan(PlanArguments::parse_from(env::args().skip(1)));
+  }
+  if env::args().nth(1).as_deref() == Some(APPLY_SUBCOMMAND) {
+    apply(ApplyArguments::parse_from(env::args().skip(1)));
+  }
+  if env::args().nth(1).as_deref() == Some(APPLY_QUICK_FIXES_SUBCOMMAND) {
+    apply_quick_fixes(ApplyQuickFixesArguments::parse_from(env::args().skip(1)));
+  }
+
   info!("Executing Polyglot Piranha");
 
   let args = PiranhaArguments::from_cli();
@@ -31,11 +101,30 @@ fn main() {
   debug!("Piranha Arguments are \n{:#?}", args);
   let piranha_output_summaries = execute_piranha(&args);
 
+  if *args.dry_run() {
+    print!("{}", get_dry_run_diff(&args, &piranha_output_summaries));
+  }
+
+  let removed_flag_usages = get_removed_flag_usages(&args, &piranha_output_summaries);
+  let flag_remnants = get_flag_remnants(&args, &piranha_output_summaries);
+
   if let Some(path) = args.path_to_output_summary() {
     write_output_summary(piranha_output_summaries, path);
   }
 
   info!("Time elapsed - {:?}", now.elapsed().as_secs());
+
+  // Reports the remnants of the `removed_flags` to clean up by hand (they are not enforced)
+  for remnant in &flag_remnants {
+    eprintln!("{remnant}");
+  }
+  // Enforces that the `removed_flags` are not used anymore
+  if !removed_flag_usages.is_empty() {
+    for usage in &removed_flag_usages {
+      eprintln!("{usage}");
+    }
+    process::exit(1);
+  }
 }
 
 /// Writes the output summaries to a Json file named `path_to_output_summaries` .
@@ -49,3 +138,57 @@ fn write_output_summary(
   }
   panic!("Could not write the output summary to the file - {path_to_json}");
 }
+
+/// Executes the `check` subcommand, exiting with a nonzero status if the output does not match the expected code base.
+fn check(check_arguments: CheckArguments) {
+  let args = PiranhaArguments::from_parsed_cli(&check_arguments.piranha_arguments);
+  debug!("Piranha Arguments are \n{:#?}", args);
+
+  let diff = check_piranha(&args, &check_arguments.path_to_expected);
+  if diff.is_empty() {
+    info!("The output matches {}", check_arguments.path_to_expected);
+    process::exit(0);
+  }
+  print!("{diff}");
+  process::exit(1);
+}
+
+/// Executes the `plan` subcommand.
+fn plan(plan_arguments: PlanArguments) {
+  let args = PiranhaArguments::from_parsed_cli(&plan_arguments.piranha_arguments);
+  debug!("Piranha Arguments are \n{:#?}", args);
+
+  let planned_files = plan_piranha(&args, &plan_arguments.path_to_plan);
+  info!(
+    "Wrote the edits of {planned_files} files to {}",
+    plan_arguments.path_to_plan
+  );
+  process::exit(0);
+}
+
+/// Executes the `apply` subcommand, exiting with a nonzero status if a planned file changed since the plan was made.
+fn apply(apply_arguments: ApplyArguments) {
+  let conflicts = apply_plan(&apply_arguments.path_to_plan);
+  if conflicts.is_empty() {
+    info!("Applied the edit plan {}", apply_arguments.path_to_plan);
+    process::exit(0);
+  }
+  for conflict in &conflicts {
+    eprintln!("{conflict} changed since the edit plan was made");
+  }
+  process::exit(1);
+}
+
+/// Executes the `apply-quick-fixes` subcommand, exiting with a nonzero status if a fixed file changed since the analysis.
+fn apply_quick_fixes(apply_quick_fixes_arguments: ApplyQuickFixesArguments) {
+  let path_to_bundle = &apply_quick_fixes_arguments.path_to_quick_fix_bundle;
+  let conflicts = apply_quick_fix_bundle(path_to_bundle);
+  if conflicts.is_empty() {
+    info!("Applied the quick-fix bundle {path_to_bundle}");
+    process::exit(0);
+  }
+  for conflict in &conflicts {
+    eprintln!("{conflict} changed since the quick-fix bundle was exported");
+  }
+  process::exit(1);
+}
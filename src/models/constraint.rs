@@ -45,21 +45,32 @@ pub struct Constraint {
   #[serde(default)]
   #[pyo3(get)]
   queries: Vec<TSQuery>,
+  /// The Tree-sitter queries that need to be applied to the other files of the package
+  /// (i.e. the files of the same directory, as for Go packages)
+  #[builder(default = "default_queries()")]
+  #[get = "pub"]
+  #[serde(default)]
+  #[pyo3(get)]
+  package_queries: Vec<TSQuery>,
 }
 
 #[pymethods]
 impl Constraint {
   #[new]
-  fn py_new(matcher: String, queries: Option<Vec<String>>) -> Self {
+  fn py_new(
+    matcher: String, queries: Option<Vec<String>>, package_queries: Option<Vec<String>>,
+  ) -> Self {
+    let to_queries = |queries: Option<Vec<String>>| {
+      queries
+        .unwrap_or_default()
+        .iter()
+        .map(|x| TSQuery::new(x.to_string()))
+        .collect_vec()
+    };
     ConstraintBuilder::default()
       .matcher(TSQuery::new(matcher))
-      .queries(
-        queries
-          .unwrap_or_default()
-          .iter()
-          .map(|x| TSQuery::new(x.to_string()))
-          .collect_vec(),
-      )
+      .queries(to_queries(queries))
+      .package_queries(to_queries(package_queries))
       .build()
       .unwrap()
   }
@@ -109,6 +120,11 @@ impl Instantiate for Constraint {
         .iter()
         .map(|x| x.instantiate(substitutions_for_holes))
         .collect_vec(),
+      package_queries: self
+        .package_queries()
+        .iter()
+        .map(|x| x.instantiate(substitutions_for_holes))
+        .collect_vec(),
     }
   }
 }
@@ -131,6 +147,7 @@ impl SourceCodeUnit {
   /// This function traverses the ancestors of the given `node` until `constraint.matcher` matches
   /// i.e. finds scope for constraint.
   /// Within this scope it checks if the `constraint.query` DOES NOT MATCH any sub-tree.
+  /// Finally, it checks if the `constraint.package_queries` DO NOT MATCH the other files of the package.
   fn _is_satisfied(
     &self, constraint: Constraint, node: Node, rule_store: &mut RuleStore,
     substitutions: &HashMap<String, String>,
@@ -165,10 +182,29 @@ impl SourceCodeUnit {
             return false;
           }
         }
+        if !constraint.package_queries().is_empty()
+          && !self.is_unused_in_package(&constraint, rule_store, substitutions)
+        {
+          return false;
+        }
         break;
       }
       current_node = parent;
     }
     matched_matcher
   }
+
+  /// Checks that none of the `constraint.package_queries` matches the other files of the package.
+  /// The files not processed yet are checked as they are on disk, hence this check is conservative.
+  fn is_unused_in_package(
+    &self, constraint: &Constraint, rule_store: &mut RuleStore,
+    substitutions: &HashMap<String, String>,
+  ) -> bool {
+    let package_queries = constraint
+      .package_queries()
+      .iter()
+      .map(|query_with_holes| query_with_holes.instantiate(substitutions))
+      .collect_vec();
+    !rule_store.matches_package_files(self.path(), &package_queries)
+  }
 }
@@ -34,6 +34,67 @@ pub const THRIFT: &str = "thrift";
 //FIXME: Remove this  hack by not passing PiranhaArguments to SourceCodeUnit
 pub(crate) const UNUSED_CODE_PATH: &str = "/dev/null";
 
+// The directive (in a comment at the top of a file) that disables the rewrites for the file
+pub(crate) const DISABLE_FILE_DIRECTIVE: &str = "piranha:disable-file";
+
+// The substitution for the flag API, required by the lint rules
+pub(crate) const LINT_FLAG_API: &str = "flag_api";
+
+// The substitution for the (regex-escaped) names of the supposedly removed flags, required by the enforcement rules
+pub(crate) const REMOVED_FLAGS: &str = "removed_flags";
+
+// The provenance comment annotating a rewritten region, e.g. `// cleaned: staleFlag (piranha)`.
+// The comment is `<line comment prefix> <PROVENANCE_COMMENT_MARKER> <flag> <PROVENANCE_COMMENT_SUFFIX>`.
+pub(crate) const PROVENANCE_COMMENT_MARKER: &str = "cleaned:";
+pub(crate) const PROVENANCE_COMMENT_SUFFIX: &str = "(piranha)";
+
+// The strategies handling the error result of the flag APIs returning `(bool, error)`.
+// The built-in rules of a strategy belong to the group `error_result_<strategy>`.
+pub const ASSUME_NIL: &str = "assume_nil";
+pub const PRESERVE_CALL: &str = "preserve_call";
+pub const KEEP_HANDLING: &str = "keep_handling";
+pub const ERROR_RESULT_HANDLING_STRATEGIES: [&str; 3] = [ASSUME_NIL, PRESERVE_CALL, KEEP_HANDLING];
+
+// The strategies handling the `ok` result of the flag APIs returning `(value, ok)` (instead of `(bool, error)`).
+// The built-in rules of a strategy belong to the group `ok_result_<strategy>`.
+pub const ASSUME_OK: &str = "assume_ok";
+pub const PRESERVE_CHECK: &str = "preserve_check";
+pub const OK_RESULT_HANDLING_STRATEGIES: [&str; 2] = [ASSUME_OK, PRESERVE_CHECK];
+
+// The group of the built-in rules preserving the flag API calls (enabled with `keep_flag_calls`),
+// and the hole of these rules for the preserved call
+pub(crate) const KEEP_FLAG_CALL_GROUP: &str = "keep_flag_call";
+pub(crate) const KEPT_FLAG_CALL: &str = "call_exp";
+
+// The group of the built-in rules replacing the deleted branch of the conditionals (enabled with
+// `deleted_branch_replacement`), and the hole of these rules for the replacement
+pub(crate) const DELETED_BRANCH_REPLACEMENT_GROUP: &str = "deleted_branch_replacement";
+pub(crate) const DELETED_BRANCH: &str = "deleted_branch";
+
+// The holes of the rename rules (enabled with `renames`), for the old and the new name of the identifier
+pub(crate) const RENAME_FROM: &str = "rename_from";
+pub(crate) const RENAME_TO: &str = "rename_to";
+// The hole of the rename rules for the name (or the import alias) of the package declaring the identifier, in the importing files
+pub(crate) const RENAME_PACKAGE: &str = "rename_package";
+// The groups of the rename rules (see `rename_rules.toml`)
+pub(crate) const RENAME_DECLARATION_GROUP: &str = "rename_declaration";
+pub(crate) const RENAME_MEMBER_DECLARATION_GROUP: &str = "rename_member_declaration";
+pub(crate) const RENAME_PACKAGE_NAME_GROUP: &str = "rename_package_name";
+pub(crate) const RENAME_COLLISION_GROUP: &str = "rename_collision";
+pub(crate) const RENAME_IMPORT_GROUP: &str = "rename_import";
+pub(crate) const RENAME_GROUP: &str = "rename";
+pub(crate) const RENAME_MEMBER_GROUP: &str = "rename_member";
+pub(crate) const RENAME_QUALIFIED_GROUP: &str = "rename_qualified";
+pub(crate) const RENAME_IMPORTED_MEMBER_GROUP: &str = "rename_imported_member";
+
+// The holes of the config value rules (enabled with `path_to_config_values`), for the fields whose value is `true`, resp. `false`
+pub(crate) const CONFIG_TRUE_FIELDS: &str = "config_true_fields";
+pub(crate) const CONFIG_FALSE_FIELDS: &str = "config_false_fields";
+
+// The maximum number of cascades of scoped (e.g. `Function-Method`) rules applied within each other.
+// Each cascade is applied recursively, thus the deeper ones are cut off to not overflow the stack.
+pub(crate) const MAX_CASCADE_DEPTH: usize = 128;
+
 pub fn default_number_of_ancestors_in_parent_scope() -> u8 {
   4
 }
@@ -90,6 +151,126 @@ pub fn default_path_to_output_summaries() -> Option<String> {
   None
 }
 
+pub fn default_path_to_junit_report() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_patch() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_run_report() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_package_heatmap() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_sarif_report() -> Option<String> {
+  None
+}
+
+pub fn default_lint_uncleanable_patterns() -> bool {
+  false
+}
+
+pub fn default_path_to_corpus() -> Option<String> {
+  None
+}
+
+pub fn default_error_result_handling() -> String {
+  ASSUME_NIL.to_string()
+}
+
+pub fn default_keep_flag_calls() -> bool {
+  false
+}
+
+pub fn default_flag_call_replacement() -> Option<String> {
+  None
+}
+
+pub fn default_dry_run_rules() -> Vec<String> {
+  vec![]
+}
+
+pub fn default_dry_run_flags() -> Vec<String> {
+  vec![]
+}
+
+pub fn default_dry_run_paths() -> Vec<Pattern> {
+  vec![]
+}
+
+pub fn default_removed_flags() -> Vec<String> {
+  vec![]
+}
+
+pub fn default_path_to_codeowners() -> Option<String> {
+  None
+}
+
+pub fn default_patch_path_prefixes() -> Vec<String> {
+  vec![]
+}
+
+pub fn default_provenance_comment() -> Option<String> {
+  None
+}
+
+pub fn default_strip_provenance_comments() -> bool {
+  false
+}
+
+pub fn default_deleted_branch_replacement() -> Option<String> {
+  None
+}
+
+pub fn default_max_nesting_depth() -> u32 {
+  1000
+}
+
+pub fn default_renames() -> Vec<String> {
+  vec![]
+}
+
+pub fn default_path_to_flag_report() -> Option<String> {
+  None
+}
+
+pub fn default_strict() -> bool {
+  false
+}
+
+pub fn default_deadline() -> u64 {
+  0
+}
+
+pub fn default_path_to_checkpoint() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_config_values() -> Option<String> {
+  None
+}
+
+pub fn default_path_to_quick_fix_bundle() -> Option<String> {
+  None
+}
+
+pub fn default_scan_flag_remnants() -> bool {
+  false
+}
+
+pub fn default_ok_result_handling() -> Option<String> {
+  None
+}
+
+pub fn default_unsupported_syntax() -> Vec<String> {
+  vec![]
+}
+
 pub fn default_piranha_language() -> PiranhaLanguage {
   PiranhaLanguage::default()
 }
@@ -98,6 +279,22 @@ pub fn default_delete_consecutive_new_lines() -> bool {
   false
 }
 
+pub fn default_disabled_builtin_rules() -> Vec<String> {
+  Vec::new()
+}
+
+pub fn default_comment_out_deletions() -> Vec<String> {
+  Vec::new()
+}
+
+pub fn default_file_size_threshold() -> u64 {
+  1024 * 1024
+}
+
+pub fn default_force_large_files() -> bool {
+  false
+}
+
 pub(crate) fn default_query() -> TSQuery {
   TSQuery::new(String::new())
 }
@@ -15,12 +15,14 @@ use std::fmt;
 
 use colored::Colorize;
 use getset::{Getters, MutGetters};
+use itertools::Itertools;
 use log::{debug, trace};
 use serde_derive::{Deserialize, Serialize};
 use tree_sitter::{Node, Range};
 
 use super::{
-  matches::Match, rule::InstantiatedRule, rule_store::RuleStore, source_code_unit::SourceCodeUnit,
+  default_configs::KEPT_FLAG_CALL, matches::Match, rule::InstantiatedRule, rule_store::RuleStore,
+  source_code_unit::SourceCodeUnit,
 };
 use crate::utilities::{
   gen_py_str_methods,
@@ -29,6 +31,9 @@ use crate::utilities::{
 };
 use pyo3::{prelude::pyclass, pymethods};
 
+/// The marker starting a code region commented out (instead of deleted) by Piranha
+const COMMENTED_OUT_MARKER: &str = "piranha:commented-out";
+
 #[derive(Serialize, Debug, Clone, Getters, MutGetters, Deserialize)]
 #[pyclass]
 pub(crate) struct Edit {
@@ -45,6 +50,10 @@ pub(crate) struct Edit {
   #[pyo3(get)]
   #[get = "pub"]
   matched_rule: String,
+  // The (byte) range of the flag API call preserved by this edit (see `keep_flag_calls`), relative to the replacement string
+  #[serde(skip)]
+  #[get = "pub"]
+  kept_call_range: Option<std::ops::Range<usize>>,
 }
 
 gen_py_str_methods!(Edit);
@@ -57,6 +66,7 @@ impl Edit {
       p_match,
       replacement_string,
       matched_rule,
+      kept_call_range: None,
     };
     if edit.is_delete() {
       edit.p_match_mut().expand_to_associated_matches(code);
@@ -74,12 +84,18 @@ impl Edit {
       ),
       replacement_string: String::new(),
       matched_rule: "Delete Range".to_string(),
+      kept_call_range: None,
     }
   }
 
   pub(crate) fn is_delete(&self) -> bool {
     self.replacement_string.trim().is_empty()
   }
+
+  /// Checks if the edit leaves the code unchanged (e.g. `inline_package_variable`).
+  pub(crate) fn is_no_op(&self) -> bool {
+    self.replacement_string.eq(self.p_match().matched_string())
+  }
 }
 
 impl fmt::Display for Edit {
@@ -143,17 +159,140 @@ impl SourceCodeUnit {
 
     return self
       .get_matches(rule, rule_store, node, recursive)
-      .first()
-      .map(|p_match| {
+      .into_iter()
+      .filter(|p_match| !self.is_kept_call(p_match))
+      .find_map(|p_match| {
         let replacement_string = rule.replace().instantiate(p_match.matches());
-        let edit = Edit::new(
-          p_match.clone(),
-          replacement_string,
-          rule.name(),
-          self.code(),
-        );
+        let mut edit = Edit::new(p_match, replacement_string, rule.name(), self.code());
+        edit.kept_call_range = rule.substitutions().get(KEPT_FLAG_CALL).and_then(|call| {
+          let start = edit.replacement_string().find(call.as_str())?;
+          Some(start..start + call.len())
+        });
+        let edit = self
+          .comment_out_deletion(edit, rule)
+          .map(|edit| self.collapse_blank_lines_around_deletion(edit));
         trace!("Rewrite found : {:#?}", edit);
         edit
       });
   }
+
+  /// Checks if the match is within a flag API call preserved by a previous edit (see `kept_call_range`).
+  /// Such a call is not rewritten again, e.g. by the rule finding the stale flag.
+  fn is_kept_call(&self, p_match: &Match) -> bool {
+    let range = p_match.range();
+    self
+      .kept_call_ranges()
+      .iter()
+      .any(|r| r.start <= range.start_byte && range.end_byte <= r.end)
+  }
+
+  /// Comments out (instead of deleting) the code deleted by `edit`, if the `rule` (or one of its groups)
+  /// is listed in `comment_out_deletions`.
+  /// The commented out code is delimited by the markers `piranha:commented-out rule=<rule name>` and `piranha:end`,
+  /// so that it can easily be restored, or deleted by a follow-up.
+  /// Only deletions spanning whole lines are commented out, the others are applied as is.
+  /// Returns `None` if the code is already commented out (i.e. the edit should be skipped).
+  fn comment_out_deletion(&self, edit: Edit, rule: &InstantiatedRule) -> Option<Edit> {
+    let is_risky = self
+      .piranha_arguments()
+      .comment_out_deletions()
+      .iter()
+      .any(|r| r.eq(&rule.name()) || rule.rule().groups().contains(r));
+    if !is_risky || !edit.is_delete() {
+      return Some(edit);
+    }
+    let matched_string = edit.p_match().matched_string();
+    if matched_string.contains(COMMENTED_OUT_MARKER) {
+      return None;
+    }
+
+    let code = self.code();
+    let range = edit.p_match().range();
+    let line_start = code[..range.start_byte].rfind('\n').map_or(0, |i| i + 1);
+    let line_end = code[range.end_byte..]
+      .find('\n')
+      .map_or(code.len(), |i| range.end_byte + i);
+    let indentation = &code[line_start..range.start_byte];
+    if !indentation.trim().is_empty() || !code[range.end_byte..line_end].trim().is_empty() {
+      return Some(edit);
+    }
+
+    let prefix = self.piranha_arguments().language().line_comment_prefix();
+    let start_marker = format!("{prefix} {COMMENTED_OUT_MARKER} rule={}", rule.name());
+    let end_marker = format!("{prefix} piranha:end");
+    let commented_out_lines = matched_string.lines().enumerate().map(|(i, line)| {
+      // Retain the indentation of the nested lines (relative to the first line)
+      let line = match i {
+        0 => line,
+        _ => line.strip_prefix(indentation).unwrap_or(line.trim_start()),
+      };
+      format!("{prefix} {line}").trim_end().to_string()
+    });
+    let replacement_string = [start_marker]
+      .into_iter()
+      .chain(commented_out_lines)
+      .chain([end_marker])
+      .join(&format!("\n{indentation}"));
+    Some(Edit::new(
+      edit.p_match().clone(),
+      replacement_string,
+      edit.matched_rule().to_string(),
+      code,
+    ))
+  }
+
+  /// Expands a deletion spanning whole lines to these lines (i.e. including the indentation and the line break),
+  /// so that the deleted code does not leave an empty line behind.
+  /// The adjacent blank line is deleted too, if it would end up next to another blank line or at the start (or end) of a block.
+  /// A deletion at the end of a line (e.g. a trailing comment) takes the preceding spaces along instead.
+  /// This matches what `gofmt` would produce, without reformatting the unrelated code.
+  fn collapse_blank_lines_around_deletion(&self, mut edit: Edit) -> Edit {
+    if !self
+      .piranha_arguments()
+      .language()
+      .collapses_blank_lines_around_deletions()
+      || !edit.is_delete()
+    {
+      return edit;
+    }
+    let code = self.code();
+    let range = edit.p_match().range();
+    let mut start = code[..range.start_byte].rfind('\n').map_or(0, |i| i + 1);
+    let mut end = code[range.end_byte..]
+      .find('\n')
+      .map_or(code.len(), |i| range.end_byte + i + 1);
+    let ends_line = code[range.end_byte..end].trim().is_empty();
+    if !code[start..range.start_byte].trim().is_empty() {
+      if ends_line {
+        let trailing_start = code[..range.start_byte].trim_end_matches([' ', '\t']).len();
+        edit
+          .p_match_mut()
+          .expand_to_byte_range(trailing_start, range.end_byte, code);
+      }
+      return edit;
+    }
+    if !ends_line {
+      return edit;
+    }
+
+    // The lines before and after the deleted lines, as (start or end offset, line)
+    let previous_line = (start > 0).then(|| {
+      let line_start = code[..start - 1].rfind('\n').map_or(0, |i| i + 1);
+      (line_start, &code[line_start..start])
+    });
+    let next_line = (end < code.len()).then(|| {
+      let line_end = code[end..].find('\n').map_or(code.len(), |i| end + i + 1);
+      (line_end, &code[end..line_end])
+    });
+    let is_blank = |line: Option<(usize, &str)>| line.map_or(false, |(_, l)| l.trim().is_empty());
+    let opens_block = previous_line.map_or(true, |(_, l)| l.trim_end().ends_with('{'));
+    let closes_block = next_line.map_or(true, |(_, l)| l.trim_start().starts_with('}'));
+    if is_blank(next_line) && (is_blank(previous_line) || opens_block) {
+      end = next_line.unwrap().0;
+    } else if is_blank(previous_line) && closes_block {
+      start = previous_line.unwrap().0;
+    }
+    edit.p_match_mut().expand_to_byte_range(start, end, code);
+    edit
+  }
 }
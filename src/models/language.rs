@@ -17,7 +17,7 @@ use getset::Getters;
 use serde_derive::Deserialize;
 use tree_sitter::{Parser, Query};
 
-use crate::utilities::parse_toml;
+use crate::utilities::{parse_toml, tree_sitter_utilities::EVAL_EQ_PREDICATE};
 
 use super::{
   default_configs::{default_language, GO, JAVA, KOTLIN, PYTHON, SWIFT, THRIFT, TSX, TYPESCRIPT},
@@ -66,6 +66,13 @@ pub enum SupportedLanguage {
 
 impl PiranhaLanguage {
   pub fn create_query(&self, query_str: String) -> Query {
+    // The string constant expressions are evaluated for Go only (see `eval_string_constant`)
+    if query_str.contains(EVAL_EQ_PREDICATE) && !self.evaluates_constants() {
+      panic!(
+        "The predicate #{} is not supported for the language {} : {:?}",
+        EVAL_EQ_PREDICATE, self.name, query_str
+      );
+    }
     let query = Query::new(self.language, query_str.as_str());
     if let Ok(q) = query {
       return q;
@@ -85,6 +92,167 @@ impl PiranhaLanguage {
     parser
   }
 
+  /// Returns the prefix of a line comment in the language
+  pub(crate) fn line_comment_prefix(&self) -> &str {
+    match self.supported_language {
+      SupportedLanguage::Python => "#",
+      _ => "//",
+    }
+  }
+
+  /// Whether the string constant expressions can be evaluated (i.e. the `#eval-eq?` predicate)
+  pub(crate) fn evaluates_constants(&self) -> bool {
+    matches!(self.supported_language, SupportedLanguage::Go)
+  }
+
+  /// Whether a deletion should not leave blank lines behind, like `gofmt` would do
+  pub(crate) fn collapses_blank_lines_around_deletions(&self) -> bool {
+    matches!(self.supported_language, SupportedLanguage::Go)
+  }
+
+  /// Returns the node kinds of the functions skipped (instead of the whole file) when they use a syntax the grammar does not support
+  pub(crate) fn function_kinds(&self) -> &'static [&'static str] {
+    match self.supported_language {
+      SupportedLanguage::Go => &["function_declaration", "method_declaration"],
+      _ => &[],
+    }
+  }
+
+  /// Returns the rules detecting the usages of the flag API that Piranha cannot clean up (if any)
+  pub(crate) fn lint_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/lint_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the rules detecting the remaining usages of the supposedly removed flags (if any)
+  pub(crate) fn enforcement_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/enforcement_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the rules detecting the mentions of the removed flags left in the comments and the string literals (if any)
+  pub(crate) fn remnant_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/remnant_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the rules renaming the identifiers surviving the cleanup (if any)
+  pub(crate) fn rename_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/rename_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the rules folding the config fields with a known value (if any)
+  pub(crate) fn config_value_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/config_value_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the rules stripping the provenance comments left by Piranha (if any)
+  pub(crate) fn provenance_rules(&self) -> Option<Rules> {
+    match self.supported_language {
+      SupportedLanguage::Go => Some(parse_toml(include_str!(
+        "../cleanup_rules/go/provenance_rules.toml"
+      ))),
+      _ => None,
+    }
+  }
+
+  /// Returns the built-in rule files of the language (i.e. their name and content), as recorded by the run manifest
+  pub(crate) fn rule_packs(&self) -> Vec<(&'static str, &'static str)> {
+    match self.supported_language {
+      SupportedLanguage::Java => vec![
+        (
+          "rules.toml",
+          include_str!("../cleanup_rules/java/rules.toml"),
+        ),
+        (
+          "edges.toml",
+          include_str!("../cleanup_rules/java/edges.toml"),
+        ),
+        (
+          "scope_config.toml",
+          include_str!("../cleanup_rules/java/scope_config.toml"),
+        ),
+      ],
+      SupportedLanguage::Go => vec![
+        ("rules.toml", include_str!("../cleanup_rules/go/rules.toml")),
+        ("edges.toml", include_str!("../cleanup_rules/go/edges.toml")),
+        (
+          "scope_config.toml",
+          include_str!("../cleanup_rules/go/scope_config.toml"),
+        ),
+        (
+          "lint_rules.toml",
+          include_str!("../cleanup_rules/go/lint_rules.toml"),
+        ),
+        (
+          "enforcement_rules.toml",
+          include_str!("../cleanup_rules/go/enforcement_rules.toml"),
+        ),
+        (
+          "remnant_rules.toml",
+          include_str!("../cleanup_rules/go/remnant_rules.toml"),
+        ),
+        (
+          "rename_rules.toml",
+          include_str!("../cleanup_rules/go/rename_rules.toml"),
+        ),
+        (
+          "config_value_rules.toml",
+          include_str!("../cleanup_rules/go/config_value_rules.toml"),
+        ),
+        (
+          "provenance_rules.toml",
+          include_str!("../cleanup_rules/go/provenance_rules.toml"),
+        ),
+      ],
+      SupportedLanguage::Kotlin => vec![
+        ("rules.toml", include_str!("../cleanup_rules/kt/rules.toml")),
+        ("edges.toml", include_str!("../cleanup_rules/kt/edges.toml")),
+        (
+          "scope_config.toml",
+          include_str!("../cleanup_rules/kt/scope_config.toml"),
+        ),
+      ],
+      SupportedLanguage::Swift => vec![
+        (
+          "rules.toml",
+          include_str!("../cleanup_rules/swift/rules.toml"),
+        ),
+        (
+          "edges.toml",
+          include_str!("../cleanup_rules/swift/edges.toml"),
+        ),
+        (
+          "scope_config.toml",
+          include_str!("../cleanup_rules/swift/scope_config.toml"),
+        ),
+      ],
+      _ => vec![],
+    }
+  }
+
   pub(crate) fn can_parse(&self, de: &jwalk::DirEntry<((), ())>) -> bool {
     de.path()
       .extension()
@@ -22,7 +22,7 @@ use tree_sitter::Node;
 
 use crate::utilities::{
   gen_py_str_methods,
-  tree_sitter_utilities::{get_all_matches_for_query, get_node_for_range},
+  tree_sitter_utilities::{get_all_matches_for_query, get_node_for_range, position_for_offset},
 };
 
 use super::{
@@ -69,6 +69,25 @@ impl Match {
       associated_comments: Vec::new(),
     }
   }
+
+  /// Returns the name of the flag captured by the match (if any), i.e. the first (by tag) string literal captured as a whole
+  /// (e.g. the `"stale_flag"` argument of `exp.BoolValue("stale_flag")`).
+  pub(crate) fn get_flag_name(&self) -> Option<String> {
+    self
+      .matches
+      .iter()
+      .sorted()
+      .map(|(_, captured)| captured.trim())
+      .find_map(|captured| {
+        ['"', '`'].into_iter().find_map(|quote| {
+          captured
+            .strip_prefix(quote)
+            .and_then(|c| c.strip_suffix(quote))
+            .filter(|name| !name.is_empty() && !name.contains(['"', '`']))
+        })
+      })
+      .map(|name| name.to_string())
+  }
   ///
   /// Returns the first and last associated ranges for the match.
   /// If there are no associated ranges, returns the range of the match itself.
@@ -103,6 +122,17 @@ impl Match {
     self.matched_string = code[self.range.start_byte..self.range.end_byte].to_string()
   }
 
+  /// Expands the match to the bytes `start_byte..end_byte` of `code` (e.g. to the whole lines of the match).
+  pub(crate) fn expand_to_byte_range(&mut self, start_byte: usize, end_byte: usize, code: &str) {
+    self.range = Range::from(tree_sitter::Range {
+      start_byte,
+      end_byte,
+      start_point: position_for_offset(code.as_bytes(), start_byte),
+      end_point: position_for_offset(code.as_bytes(), end_byte),
+    });
+    self.matched_string = code[start_byte..end_byte].to_string()
+  }
+
   /// Get the edit's replacement range.
   pub(crate) fn range(&self) -> tree_sitter::Range {
     tree_sitter::Range {
@@ -290,6 +320,7 @@ impl SourceCodeUnit {
     } else {
       Some(rule.replace_node())
     };
+    let is_rewrite = replace_node_tag.is_some();
     let mut all_query_matches = get_all_matches_for_query(
       &node,
       self.code().to_string(),
@@ -299,12 +330,28 @@ impl SourceCodeUnit {
     );
 
     // Return the first match that satisfies constraint of the rule
+    // The code of the skipped functions is left untouched, and so are the declarations they refer to
+    let skipped_ranges = self.skipped_function_ranges();
+    let skipped_references = self.skipped_function_references();
     for p_match in all_query_matches.iter_mut() {
+      let range = p_match.range();
+      if skipped_ranges
+        .iter()
+        .any(|r| range.start_byte < r.end_byte && r.start_byte < range.end_byte)
+      {
+        continue;
+      }
       let matched_node = get_node_for_range(
         self.root_node(),
         p_match.range().start_byte,
         p_match.range().end_byte,
       );
+      if is_rewrite
+        && !skipped_references.is_empty()
+        && self.declares_any(matched_node, &skipped_references)
+      {
+        continue;
+      }
       if self.is_satisfied(matched_node, rule, p_match.matches(), rule_store) {
         p_match.populate_associated_elements(&matched_node, self.code(), self.piranha_arguments());
         trace!("Found match {:#?}", p_match);
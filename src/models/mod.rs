@@ -19,8 +19,10 @@ pub(crate) mod matches;
 pub(crate) mod outgoing_edges;
 pub mod piranha_arguments;
 pub mod piranha_output;
+pub(crate) mod provenance;
 pub(crate) mod rule;
 pub(crate) mod rule_graph;
 pub(crate) mod rule_store;
 pub(crate) mod scopes;
 pub(crate) mod source_code_unit;
+pub(crate) mod unsupported_syntax;
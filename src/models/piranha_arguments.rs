@@ -14,13 +14,29 @@ Copyright (c) 2023 Uber Technologies, Inc.
 use super::{
   default_configs::{
     default_allow_dirty_ast, default_cleanup_comments, default_cleanup_comments_buffer,
-    default_code_snippet, default_delete_consecutive_new_lines, default_delete_file_if_empty,
-    default_dry_run, default_exclude, default_global_tag_prefix, default_include,
-    default_number_of_ancestors_in_parent_scope, default_path_to_codebase,
-    default_path_to_configurations, default_path_to_output_summaries, default_piranha_language,
-    default_rule_graph, default_substitutions, GO, JAVA, KOTLIN, PYTHON, SWIFT, TSX, TYPESCRIPT,
+    default_code_snippet, default_comment_out_deletions, default_deadline,
+    default_delete_consecutive_new_lines, default_delete_file_if_empty,
+    default_deleted_branch_replacement, default_disabled_builtin_rules, default_dry_run,
+    default_dry_run_flags, default_dry_run_paths, default_dry_run_rules,
+    default_error_result_handling, default_exclude, default_file_size_threshold,
+    default_flag_call_replacement, default_force_large_files, default_global_tag_prefix,
+    default_include, default_keep_flag_calls, default_lint_uncleanable_patterns,
+    default_max_nesting_depth, default_number_of_ancestors_in_parent_scope,
+    default_ok_result_handling, default_patch_path_prefixes, default_path_to_checkpoint,
+    default_path_to_codebase, default_path_to_codeowners, default_path_to_config_values,
+    default_path_to_configurations, default_path_to_corpus, default_path_to_flag_report,
+    default_path_to_junit_report, default_path_to_output_summaries,
+    default_path_to_package_heatmap, default_path_to_patch, default_path_to_quick_fix_bundle,
+    default_path_to_run_report, default_path_to_sarif_report, default_piranha_language,
+    default_provenance_comment, default_removed_flags, default_renames, default_rule_graph,
+    default_scan_flag_remnants, default_strict, default_strip_provenance_comments,
+    default_substitutions, default_unsupported_syntax, CONFIG_FALSE_FIELDS, CONFIG_TRUE_FIELDS,
+    DELETED_BRANCH, DELETED_BRANCH_REPLACEMENT_GROUP, ERROR_RESULT_HANDLING_STRATEGIES, GO, JAVA,
+    KEEP_FLAG_CALL_GROUP, KEPT_FLAG_CALL, KOTLIN, LINT_FLAG_API, OK_RESULT_HANDLING_STRATEGIES,
+    PYTHON, REMOVED_FLAGS, SWIFT, TSX, TYPESCRIPT,
   },
   language::PiranhaLanguage,
+  rule::Rule,
   rule_graph::{read_user_config_files, RuleGraph, RuleGraphBuilder},
   source_code_unit::SourceCodeUnit,
 };
@@ -38,7 +54,10 @@ use pyo3::{
 };
 use regex::Regex;
 
-use std::collections::HashMap;
+use std::{
+  collections::{HashMap, HashSet},
+  fs,
+};
 
 /// A refactoring tool that eliminates dead code related to stale feature flags
 #[derive(Clone, Getters, CopyGetters, Debug, Parser, Builder)]
@@ -87,6 +106,73 @@ pub struct PiranhaArguments {
   #[builder(default = "default_path_to_output_summaries()")]
   #[clap(short = 'j', long)]
   path_to_output_summary: Option<String>,
+
+  /// Path to the JUnit XML report, where each analyzed file is a test case
+  #[get = "pub"]
+  #[builder(default = "default_path_to_junit_report()")]
+  #[clap(long)]
+  path_to_junit_report: Option<String>,
+
+  /// Path to the patch file (unified diff) of the edits performed by Piranha
+  #[get = "pub"]
+  #[builder(default = "default_path_to_patch()")]
+  #[clap(long)]
+  path_to_patch: Option<String>,
+
+  /// Path to the run report json file, that reports whether the run was complete or partial (i.e. interrupted by an internal error)
+  #[get = "pub"]
+  #[builder(default = "default_path_to_run_report()")]
+  #[clap(long)]
+  path_to_run_report: Option<String>,
+
+  /// Path to the package heatmap json file, that ranks the packages by the number of matches and rewrites
+  #[get = "pub"]
+  #[builder(default = "default_path_to_package_heatmap()")]
+  #[clap(long)]
+  path_to_package_heatmap: Option<String>,
+
+  /// Path to the SARIF report of the matches (e.g. of the usages that cannot be cleaned up)
+  #[get = "pub"]
+  #[builder(default = "default_path_to_sarif_report()")]
+  #[clap(long)]
+  path_to_sarif_report: Option<String>,
+
+  /// Directory of the regression corpus, where the (input, expected) pairs of the edited files are recorded as a new case
+  #[get = "pub"]
+  #[builder(default = "default_path_to_corpus()")]
+  #[clap(long)]
+  path_to_corpus: Option<String>,
+
+  /// Path to a CODEOWNERS file. The patch is split into one patch file per owner (e.g. `edits.payments-team.patch` for `--path-to-patch edits.patch`), requires `path_to_patch`
+  #[get = "pub"]
+  #[builder(default = "default_path_to_codeowners()")]
+  #[clap(long)]
+  path_to_codeowners: Option<String>,
+
+  /// Path to the flag report json file, that groups the edits by flag, then by package and file (e.g. to review the cleanup of each flag of a multi-flag run independently)
+  #[get = "pub"]
+  #[builder(default = "default_path_to_flag_report()")]
+  #[clap(long)]
+  path_to_flag_report: Option<String>,
+
+  /// Path to the checkpoint json file. It is written when the `deadline` is reached, and a later run with the same checkpoint resumes from it (i.e. only processes the remaining files)
+  #[get = "pub"]
+  #[builder(default = "default_path_to_checkpoint()")]
+  #[clap(long)]
+  path_to_checkpoint: Option<String>,
+
+  /// Path to the toml file of the known config values (e.g. `"cfg.Features.NewFlow" = true`). The config fields gating the code along with a flag (e.g. `exp.BoolValue(flag) && cfg.Features.NewFlow`) are folded too, once the flag is folded (only Go for now)
+  #[get = "pub"]
+  #[builder(default = "default_path_to_config_values()")]
+  #[clap(long)]
+  path_to_config_values: Option<String>,
+
+  /// Path to the quick-fix bundle json file, i.e. the edits as machine-applicable fixes (file, range and replacement) along with the checksum of the original content of each file, so that other tools (e.g. editors) apply them later, refusing the files that changed since the analysis
+  #[get = "pub"]
+  #[builder(default = "default_path_to_quick_fix_bundle()")]
+  #[clap(long)]
+  path_to_quick_fix_bundle: Option<String>,
+
   /// The target language
   #[get = "pub"]
   #[builder(default = "default_piranha_language()")]
@@ -146,6 +232,144 @@ pub struct PiranhaArguments {
   #[builder(default = "default_allow_dirty_ast()")]
   #[clap(long, default_value_t = default_allow_dirty_ast())]
   allow_dirty_ast: bool,
+
+  /// Names of the built-in cleanup rules (or groups of rules) to disable
+  #[get = "pub"]
+  #[builder(default = "default_disabled_builtin_rules()")]
+  #[clap(long, num_args = 0.., required = false)]
+  disabled_builtin_rules: Vec<String>,
+
+  /// Names of the rules (or groups of rules) whose deletions are commented out (with a `piranha:commented-out` marker) instead, e.g. the risky ones
+  #[get = "pub"]
+  #[builder(default = "default_comment_out_deletions()")]
+  #[clap(long, num_args = 0.., required = false)]
+  comment_out_deletions: Vec<String>,
+
+  /// Files larger than this threshold (in bytes) are reported but not edited
+  #[get = "pub"]
+  #[builder(default = "default_file_size_threshold()")]
+  #[clap(long, default_value_t = default_file_size_threshold())]
+  file_size_threshold: u64,
+
+  /// Edits the files larger than `file_size_threshold` too
+  #[get = "pub"]
+  #[builder(default = "default_force_large_files()")]
+  #[clap(long, default_value_t = default_force_large_files())]
+  force_large_files: bool,
+
+  /// Detects the usages of the flag API that cannot be cleaned up (e.g. dynamic flag names). The flag API is provided as the substitution `flag_api`
+  #[get = "pub"]
+  #[builder(default = "default_lint_uncleanable_patterns()")]
+  #[clap(long, default_value_t = default_lint_uncleanable_patterns())]
+  lint_uncleanable_patterns: bool,
+
+  /// How the error result of the flag APIs returning `(bool, error)` is handled : `assume_nil` (deletes its handling), `preserve_call` (keeps the call for its side effects) or `keep_handling`
+  #[get = "pub"]
+  #[builder(default = "default_error_result_handling()")]
+  #[clap(long, default_value_t = default_error_result_handling(), value_parser = clap::builder::PossibleValuesParser::new(ERROR_RESULT_HANDLING_STRATEGIES))]
+  error_result_handling: String,
+
+  /// Preserves the flag API calls as standalone statements (e.g. since they record exposure events), when the conditionals are replaced with the treated branch
+  #[get = "pub"]
+  #[builder(default = "default_keep_flag_calls()")]
+  #[clap(long, default_value_t = default_keep_flag_calls())]
+  keep_flag_calls: bool,
+
+  /// The no-op call replacing the flag API calls preserved with `keep_flag_calls` (e.g. `exp.RecordExposure(@arg_str_literal)`), its tags are filled from the rule finding the stale flag
+  #[get = "pub"]
+  #[builder(default = "default_flag_call_replacement()")]
+  #[clap(long)]
+  flag_call_replacement: Option<String>,
+
+  /// In dry-run mode, only prints the diffs of the files rewritten by these rules (e.g. the risky rules)
+  #[get = "pub"]
+  #[builder(default = "default_dry_run_rules()")]
+  #[clap(long, num_args = 0.., required = false)]
+  dry_run_rules: Vec<String>,
+
+  /// In dry-run mode, only prints the diffs of the files where these flags were found (i.e. captured by a rule)
+  #[get = "pub"]
+  #[builder(default = "default_dry_run_flags()")]
+  #[clap(long, num_args = 0.., required = false)]
+  dry_run_flags: Vec<String>,
+
+  /// In dry-run mode, only prints the diffs of the files matching these paths (as glob patterns)
+  #[get = "pub"]
+  #[builder(default = "default_dry_run_paths()")]
+  #[clap(long, value_parser = parse_glob_pattern, num_args = 0.., required = false)]
+  dry_run_paths: Vec<Pattern>,
+
+  /// The names of the flags that are supposed to be removed. Piranha reports their remaining usages (and exits with a nonzero status via the CLI). Requires the substitution `flag_api`.
+  #[get = "pub"]
+  #[builder(default = "default_removed_flags()")]
+  #[clap(long, num_args = 0.., required = false)]
+  removed_flags: Vec<String>,
+
+  /// Path prefixes (relative to the code base) along which the patch is split, one patch file per prefix (e.g. `edits.services_payments.patch` for `services/payments`), requires `path_to_patch`
+  #[get = "pub"]
+  #[builder(default = "default_patch_path_prefixes()")]
+  #[clap(long, num_args = 0.., required = false)]
+  patch_path_prefixes: Vec<String>,
+
+  /// The flag named in the provenance comment (e.g. `// cleaned: staleFlag (piranha)`) annotating each rewritten region that survives the cleanup. A follow-up run can strip these comments with `strip_provenance_comments`
+  #[get = "pub"]
+  #[builder(default = "default_provenance_comment()")]
+  #[clap(long)]
+  provenance_comment: Option<String>,
+
+  /// Strips the provenance comments (e.g. `// cleaned: staleFlag (piranha)`) left by a previous run with `provenance_comment`
+  #[get = "pub"]
+  #[builder(default = "default_strip_provenance_comments()")]
+  #[clap(long, default_value_t = default_strip_provenance_comments())]
+  strip_provenance_comments: bool,
+
+  /// The statement replacing the deleted (untreated) branch of the conditionals, e.g. a metric increment or a debug log recording the fallback. By default, the branch is deleted
+  #[get = "pub"]
+  #[builder(default = "default_deleted_branch_replacement()")]
+  #[clap(long)]
+  deleted_branch_replacement: Option<String>,
+
+  /// The files whose syntax tree is nested deeper than this (e.g. generated code) are reported but not edited
+  #[get = "pub"]
+  #[builder(default = "default_max_nesting_depth()")]
+  #[clap(long, default_value_t = default_max_nesting_depth())]
+  max_nesting_depth: u32,
+
+  /// The identifiers to rename after the cleanup, as `old=new` pairs (e.g. `newCheckoutFlow=checkoutFlow`). The declaration named `old` surviving the cleanup (a function, a type, a variable, a method or a field) is renamed along with its usages by its package and the importing packages. The renames are refused if several packages declare `old`, or if its package already uses `new`, and are listed in the run report (only Go for now)
+  #[get = "pub"]
+  #[builder(default = "default_renames()")]
+  #[clap(long, num_args = 0.., required = false)]
+  renames: Vec<String>,
+
+  /// Fails the whole run (without editing the code base) if a usage could not be cleaned up, e.g. an uncleanable pattern (as found with `lint_uncleanable_patterns`), or a file that was not (fully) edited. The blockers are listed. Requires the substitution `flag_api`
+  #[get = "pub"]
+  #[builder(default = "default_strict()")]
+  #[clap(long, default_value_t = default_strict())]
+  strict: bool,
+
+  /// The time budget of the run (in seconds, 0 for none). Once it is reached, the files in flight are finished, and the remaining files are written to the checkpoint `path_to_checkpoint`
+  #[get = "pub"]
+  #[builder(default = "default_deadline()")]
+  #[clap(long, default_value_t = default_deadline())]
+  deadline: u64,
+
+  /// Reports (without editing them) the mentions of the `removed_flags` left in the comments, the string literals and the struct tags, i.e. the remnants the cleanup rules do not cover (only Go for now)
+  #[get = "pub"]
+  #[builder(default = "default_scan_flag_remnants()")]
+  #[clap(long, default_value_t = default_scan_flag_remnants())]
+  scan_flag_remnants: bool,
+
+  /// How the `ok` result of the flag APIs returning `(value, ok)` (instead of `(bool, error)`) is handled : `assume_ok` (simplifies its checks) or `preserve_check` (keeps the call and its checks). If not specified, the flag APIs return `(bool, error)`, whose error is handled as per `error_result_handling`
+  #[get = "pub"]
+  #[builder(default = "default_ok_result_handling()")]
+  #[clap(long, value_parser = clap::builder::PossibleValuesParser::new(OK_RESULT_HANDLING_STRATEGIES))]
+  ok_result_handling: Option<String>,
+
+  /// The constructs the grammar does not support (e.g. a syntax newer than the bundled grammar), as regexes matched against the lines of the syntax errors. The functions using them are skipped, instead of failing the whole file (only Go for now)
+  #[get = "pub"]
+  #[builder(default = "default_unsupported_syntax()")]
+  #[clap(long, num_args = 0.., required = false)]
+  unsupported_syntax: Vec<String>,
 }
 
 impl Default for PiranhaArguments {
@@ -174,6 +398,40 @@ impl PiranhaArguments {
   /// * delete_file_if_empty (bool): User option that determines whether an empty file will be deleted
   /// * path_to_output_summary : Path to the file where the Piranha output summary should be persisted
   /// * allow_dirty_ast : Allows syntax errors in the input source code
+  /// * file_size_threshold (u64) : Files larger than this threshold (in bytes) are reported but not edited
+  /// * force_large_files (bool) : Edits the files larger than `file_size_threshold` too
+  /// * path_to_junit_report : Path to the JUnit XML report, where each analyzed file is a test case
+  /// * disabled_builtin_rules (list[str]) : Names of the built-in cleanup rules (or groups of rules) to disable
+  /// * path_to_patch : Path to the patch file (unified diff) of the edits performed by Piranha
+  /// * path_to_run_report : Path to the run report, that reports whether the run was complete or partial
+  /// * path_to_package_heatmap : Path to the package heatmap, that ranks the packages by the number of matches and rewrites
+  /// * comment_out_deletions (list[str]) : Names of the rules (or groups of rules) whose deletions are commented out instead
+  /// * path_to_sarif_report : Path to the SARIF report of the matches
+  /// * lint_uncleanable_patterns (bool) : Detects the usages of the flag API that cannot be cleaned up
+  /// * path_to_corpus : Directory of the regression corpus, where the edited files are recorded as a new case
+  /// * error_result_handling (string) : How the error result of the flag APIs returning `(bool, error)` is handled
+  /// * keep_flag_calls (bool) : Preserves the flag API calls as standalone statements, when the conditionals are replaced with the treated branch
+  /// * flag_call_replacement : The no-op call replacing the flag API calls preserved with `keep_flag_calls`
+  /// * dry_run_rules (list[str]) : In dry-run mode, only prints the diffs of the files rewritten by these rules
+  /// * dry_run_flags (list[str]) : In dry-run mode, only prints the diffs of the files where these flags were found
+  /// * dry_run_paths (list[str]) : In dry-run mode, only prints the diffs of the files matching these paths (as glob patterns)
+  /// * removed_flags (list[str]) : The names of the supposedly removed flags, whose remaining usages are reported
+  /// * path_to_codeowners : Path to a CODEOWNERS file, along which the patch is split (one patch file per owner)
+  /// * patch_path_prefixes (list[str]) : Path prefixes along which the patch is split (one patch file per prefix)
+  /// * provenance_comment : The flag named in the provenance comment (e.g. `// cleaned: staleFlag (piranha)`) annotating each surviving rewritten region
+  /// * strip_provenance_comments (bool) : Strips the provenance comments left by a previous run with `provenance_comment`
+  /// * deleted_branch_replacement : The statement replacing the deleted (untreated) branch of the conditionals (e.g. a metric increment)
+  /// * max_nesting_depth (u32) : The files whose syntax tree is nested deeper than this (e.g. generated code) are reported but not edited
+  /// * renames (list[str]) : The identifiers to rename after the cleanup (as `old=new` pairs), e.g. the transitional names of the surviving functions
+  /// * path_to_flag_report : Path to the flag report json file, that groups the edits by flag, then by package and file
+  /// * strict (bool) : Fails the whole run (without editing the code base) if a usage could not be cleaned up, listing the blockers
+  /// * deadline (u64) : The time budget of the run (in seconds, 0 for none), after which the remaining files are written to the checkpoint
+  /// * path_to_checkpoint : Path to the checkpoint json file, written when the `deadline` is reached and resumed from by a later run
+  /// * path_to_config_values : Path to the toml file of the known config values, folded along with the flags they are compared with
+  /// * path_to_quick_fix_bundle : Path to the quick-fix bundle json file, i.e. the edits as machine-applicable fixes along with the checksum of each file
+  /// * scan_flag_remnants (bool) : Reports the mentions of the `removed_flags` left in the comments, the string literals and the struct tags
+  /// * ok_result_handling : How the `ok` result of the flag APIs returning `(value, ok)` is handled : `assume_ok` or `preserve_check`
+  /// * unsupported_syntax (list[str]) : The constructs the grammar does not support (as regexes), the functions using them are skipped
   /// Returns PiranhaArgument.
   #[new]
   fn py_new(
@@ -184,7 +442,23 @@ impl PiranhaArguments {
     cleanup_comments_buffer: Option<i32>, number_of_ancestors_in_parent_scope: Option<u8>,
     delete_consecutive_new_lines: Option<bool>, global_tag_prefix: Option<String>,
     delete_file_if_empty: Option<bool>, path_to_output_summary: Option<String>,
-    allow_dirty_ast: Option<bool>,
+    allow_dirty_ast: Option<bool>, file_size_threshold: Option<u64>,
+    force_large_files: Option<bool>, path_to_junit_report: Option<String>,
+    disabled_builtin_rules: Option<Vec<String>>, path_to_patch: Option<String>,
+    path_to_run_report: Option<String>, path_to_package_heatmap: Option<String>,
+    comment_out_deletions: Option<Vec<String>>, path_to_sarif_report: Option<String>,
+    lint_uncleanable_patterns: Option<bool>, path_to_corpus: Option<String>,
+    error_result_handling: Option<String>, keep_flag_calls: Option<bool>,
+    flag_call_replacement: Option<String>, dry_run_rules: Option<Vec<String>>,
+    dry_run_flags: Option<Vec<String>>, dry_run_paths: Option<Vec<String>>,
+    removed_flags: Option<Vec<String>>, path_to_codeowners: Option<String>,
+    patch_path_prefixes: Option<Vec<String>>, provenance_comment: Option<String>,
+    strip_provenance_comments: Option<bool>, deleted_branch_replacement: Option<String>,
+    max_nesting_depth: Option<u32>, renames: Option<Vec<String>>,
+    path_to_flag_report: Option<String>, strict: Option<bool>, deadline: Option<u64>,
+    path_to_checkpoint: Option<String>, path_to_config_values: Option<String>,
+    path_to_quick_fix_bundle: Option<String>, scan_flag_remnants: Option<bool>,
+    ok_result_handling: Option<String>, unsupported_syntax: Option<Vec<String>>,
   ) -> Self {
     let subs = if substitutions.is_some() {
       substitutions
@@ -234,6 +508,50 @@ impl PiranhaArguments {
       .delete_file_if_empty(delete_file_if_empty.unwrap_or_else(default_delete_file_if_empty))
       .path_to_output_summary(path_to_output_summary)
       .allow_dirty_ast(allow_dirty_ast.unwrap_or_else(default_allow_dirty_ast))
+      .file_size_threshold(file_size_threshold.unwrap_or_else(default_file_size_threshold))
+      .force_large_files(force_large_files.unwrap_or_else(default_force_large_files))
+      .path_to_junit_report(path_to_junit_report)
+      .disabled_builtin_rules(disabled_builtin_rules.unwrap_or_else(default_disabled_builtin_rules))
+      .path_to_patch(path_to_patch)
+      .path_to_run_report(path_to_run_report)
+      .path_to_package_heatmap(path_to_package_heatmap)
+      .comment_out_deletions(comment_out_deletions.unwrap_or_else(default_comment_out_deletions))
+      .path_to_sarif_report(path_to_sarif_report)
+      .lint_uncleanable_patterns(
+        lint_uncleanable_patterns.unwrap_or_else(default_lint_uncleanable_patterns),
+      )
+      .path_to_corpus(path_to_corpus)
+      .error_result_handling(error_result_handling.unwrap_or_else(default_error_result_handling))
+      .keep_flag_calls(keep_flag_calls.unwrap_or_else(default_keep_flag_calls))
+      .flag_call_replacement(flag_call_replacement)
+      .dry_run_rules(dry_run_rules.unwrap_or_else(default_dry_run_rules))
+      .dry_run_flags(dry_run_flags.unwrap_or_else(default_dry_run_flags))
+      .dry_run_paths(
+        dry_run_paths
+          .unwrap_or_default()
+          .iter()
+          .map(|x| Pattern::new(x).unwrap())
+          .collect_vec(),
+      )
+      .removed_flags(removed_flags.unwrap_or_else(default_removed_flags))
+      .path_to_codeowners(path_to_codeowners)
+      .patch_path_prefixes(patch_path_prefixes.unwrap_or_else(default_patch_path_prefixes))
+      .provenance_comment(provenance_comment)
+      .strip_provenance_comments(
+        strip_provenance_comments.unwrap_or_else(default_strip_provenance_comments),
+      )
+      .deleted_branch_replacement(deleted_branch_replacement)
+      .max_nesting_depth(max_nesting_depth.unwrap_or_else(default_max_nesting_depth))
+      .renames(renames.unwrap_or_else(default_renames))
+      .path_to_flag_report(path_to_flag_report)
+      .strict(strict.unwrap_or_else(default_strict))
+      .deadline(deadline.unwrap_or_else(default_deadline))
+      .path_to_checkpoint(path_to_checkpoint)
+      .path_to_config_values(path_to_config_values)
+      .path_to_quick_fix_bundle(path_to_quick_fix_bundle)
+      .scan_flag_remnants(scan_flag_remnants.unwrap_or_else(default_scan_flag_remnants))
+      .ok_result_handling(ok_result_handling)
+      .unsupported_syntax(unsupported_syntax.unwrap_or_else(default_unsupported_syntax))
       .build()
   }
 }
@@ -244,7 +562,11 @@ impl PiranhaArguments {
   }
 
   pub fn from_cli() -> Self {
-    let p = PiranhaArguments::parse();
+    Self::from_parsed_cli(&PiranhaArguments::parse())
+  }
+
+  /// Builds the Piranha arguments from the parsed command line arguments (e.g. flattened in the arguments of a subcommand).
+  pub fn from_parsed_cli(p: &PiranhaArguments) -> Self {
     PiranhaArgumentsBuilder::default()
       .path_to_codebase(p.path_to_codebase().to_string())
       .substitutions(p.substitutions.clone())
@@ -258,11 +580,64 @@ impl PiranhaArguments {
       .cleanup_comments_buffer(*p.cleanup_comments_buffer())
       .cleanup_comments(*p.cleanup_comments())
       .dry_run(*p.dry_run())
+      .file_size_threshold(*p.file_size_threshold())
+      .force_large_files(*p.force_large_files())
+      .path_to_junit_report(p.path_to_junit_report().clone())
+      .disabled_builtin_rules(p.disabled_builtin_rules().clone())
+      .path_to_patch(p.path_to_patch().clone())
+      .path_to_run_report(p.path_to_run_report().clone())
+      .path_to_package_heatmap(p.path_to_package_heatmap().clone())
+      .comment_out_deletions(p.comment_out_deletions().clone())
+      .path_to_sarif_report(p.path_to_sarif_report().clone())
+      .lint_uncleanable_patterns(*p.lint_uncleanable_patterns())
+      .path_to_corpus(p.path_to_corpus().clone())
+      .error_result_handling(p.error_result_handling().clone())
+      .keep_flag_calls(*p.keep_flag_calls())
+      .flag_call_replacement(p.flag_call_replacement().clone())
+      .dry_run_rules(p.dry_run_rules().clone())
+      .dry_run_flags(p.dry_run_flags().clone())
+      .dry_run_paths(p.dry_run_paths().clone())
+      .removed_flags(p.removed_flags().clone())
+      .path_to_codeowners(p.path_to_codeowners().clone())
+      .patch_path_prefixes(p.patch_path_prefixes().clone())
+      .provenance_comment(p.provenance_comment().clone())
+      .strip_provenance_comments(*p.strip_provenance_comments())
+      .deleted_branch_replacement(p.deleted_branch_replacement().clone())
+      .max_nesting_depth(*p.max_nesting_depth())
+      .renames(p.renames().clone())
+      .path_to_flag_report(p.path_to_flag_report().clone())
+      .strict(*p.strict())
+      .deadline(*p.deadline())
+      .path_to_checkpoint(p.path_to_checkpoint().clone())
+      .path_to_config_values(p.path_to_config_values().clone())
+      .path_to_quick_fix_bundle(p.path_to_quick_fix_bundle().clone())
+      .scan_flag_remnants(*p.scan_flag_remnants())
+      .ok_result_handling(p.ok_result_handling().clone())
+      .unsupported_syntax(p.unsupported_syntax().clone())
       .build()
   }
 
+  /// Returns the substitutions, including the alternation of the (escaped) `removed_flags`.
   pub(crate) fn input_substitutions(&self) -> HashMap<String, String> {
-    self.substitutions.iter().cloned().collect()
+    let mut substitutions: HashMap<String, String> = self.substitutions.iter().cloned().collect();
+    if !self.removed_flags.is_empty() {
+      let removed_flags = self
+        .removed_flags
+        .iter()
+        .map(|f| escape_flag_name(f.as_str()))
+        .join("|");
+      substitutions.insert(REMOVED_FLAGS.to_string(), removed_flags);
+    }
+    substitutions
+  }
+
+  /// Returns the `renames` as (old name, new name) pairs.
+  pub(crate) fn get_renames(&self) -> Vec<(String, String)> {
+    self
+      .renames
+      .iter()
+      .filter_map(|r| parse_rename(r))
+      .collect()
   }
 }
 
@@ -302,10 +677,170 @@ impl PiranhaArgumentsBuilder {
       );
     }
 
+    if *_arg.lint_uncleanable_patterns() && !_arg.input_substitutions().contains_key(LINT_FLAG_API)
+    {
+      return Err(format!(
+        "Invalid Piranha arguments. Please specify the substitution `{LINT_FLAG_API}` (e.g. `BoolValue|StrValue`) when `lint_uncleanable_patterns` is enabled."
+      ));
+    }
+
+    if *_arg.strict() && !_arg.input_substitutions().contains_key(LINT_FLAG_API) {
+      return Err(format!(
+        "Invalid Piranha arguments. Please specify the substitution `{LINT_FLAG_API}` (e.g. `BoolValue|StrValue`) when `strict` is enabled."
+      ));
+    }
+
+    if *_arg.scan_flag_remnants() && _arg.removed_flags().is_empty() {
+      return Err(
+        "Invalid Piranha arguments. Please specify the `removed_flags` when `scan_flag_remnants` is enabled."
+          .to_string(),
+      );
+    }
+
+    if *_arg.deadline() > 0 && _arg.path_to_checkpoint().is_none() {
+      return Err(
+        "Invalid Piranha arguments. Please specify the `path_to_checkpoint` when a `deadline` is set."
+          .to_string(),
+      );
+    }
+
+    if !_arg.removed_flags().is_empty() && !_arg.input_substitutions().contains_key(LINT_FLAG_API) {
+      return Err(format!(
+        "Invalid Piranha arguments. Please specify the substitution `{LINT_FLAG_API}` (e.g. `BoolValue|StrValue`) when `removed_flags` are specified."
+      ));
+    }
+
+    if _arg.flag_call_replacement().is_some() && !*_arg.keep_flag_calls() {
+      return Err(
+        "Invalid Piranha arguments. The `flag_call_replacement` requires `keep_flag_calls` to be enabled."
+          .to_string(),
+      );
+    }
+
+    let has_dry_run_filters = !_arg.dry_run_rules().is_empty()
+      || !_arg.dry_run_flags().is_empty()
+      || !_arg.dry_run_paths().is_empty();
+    if has_dry_run_filters && !*_arg.dry_run() {
+      return Err(
+        "Invalid Piranha arguments. The `dry_run_rules`, `dry_run_flags` and `dry_run_paths` require `dry_run` to be enabled."
+          .to_string(),
+      );
+    }
+
+    let splits_patch =
+      _arg.path_to_codeowners().is_some() || !_arg.patch_path_prefixes().is_empty();
+    if splits_patch && _arg.path_to_patch().is_none() {
+      return Err(
+        "Invalid Piranha arguments. The `path_to_codeowners` and `patch_path_prefixes` require `path_to_patch`."
+          .to_string(),
+      );
+    }
+
+    if _arg.path_to_codeowners().is_some() && !_arg.patch_path_prefixes().is_empty() {
+      return Err(
+        "Invalid Piranha arguments. Please either specify the `path_to_codeowners` or the `patch_path_prefixes`. Not Both."
+          .to_string(),
+      );
+    }
+
+    if _arg.provenance_comment().is_some() && *_arg.strip_provenance_comments() {
+      return Err(
+        "Invalid Piranha arguments. Please either specify the `provenance_comment` or enable `strip_provenance_comments`. Not Both."
+          .to_string(),
+      );
+    }
+
+    if let Some(rename) = _arg.renames().iter().find(|r| parse_rename(r).is_none()) {
+      return Err(format!(
+        "Invalid Piranha arguments. The `renames` should be `old=new` pairs of identifiers, found `{rename}`."
+      ));
+    }
+
+    if let Some(path) = _arg.path_to_config_values() {
+      read_config_values(path)?;
+    }
+
+    if !ERROR_RESULT_HANDLING_STRATEGIES.contains(&_arg.error_result_handling().as_str()) {
+      return Err(format!(
+        "Invalid Piranha arguments. The `error_result_handling` should be one of {ERROR_RESULT_HANDLING_STRATEGIES:?}, found `{}`.",
+        _arg.error_result_handling()
+      ));
+    }
+
+    if let Some(ok_result_handling) = _arg.ok_result_handling() {
+      if !OK_RESULT_HANDLING_STRATEGIES.contains(&ok_result_handling.as_str()) {
+        return Err(format!(
+          "Invalid Piranha arguments. The `ok_result_handling` should be one of {OK_RESULT_HANDLING_STRATEGIES:?}, found `{ok_result_handling}`."
+        ));
+      }
+    }
+
+    if let Some(pattern) = _arg
+      .unsupported_syntax()
+      .iter()
+      .find(|p| Regex::new(p).is_err())
+    {
+      return Err(format!(
+        "Invalid Piranha arguments. The `unsupported_syntax` pattern `{pattern}` is not a valid regex."
+      ));
+    }
+
     Ok(true)
   }
 }
 
+/// Reads the known config values (by selector) from the toml file `path_to_config_values`.
+fn read_config_values(path_to_config_values: &str) -> Result<HashMap<String, bool>, String> {
+  let config_values: HashMap<String, bool> = fs::read_to_string(path_to_config_values)
+    .ok()
+    .and_then(|content| toml::from_str(&content).ok())
+    .ok_or(format!(
+      "Invalid Piranha arguments. Could not read the config values (e.g. `\"cfg.Features.NewFlow\" = true`) from the file - {path_to_config_values}"
+    ))?;
+  if let Some(field) = config_values.keys().find(|f| !is_selector(f)) {
+    return Err(format!(
+      "Invalid Piranha arguments. The config values should be keyed by selectors (e.g. `cfg.Features.NewFlow`), found `{field}`."
+    ));
+  }
+  Ok(config_values)
+}
+
+/// Whether `name` is a selector of a field, i.e. identifiers separated by dots (e.g. `cfg.Features.NewFlow`).
+fn is_selector(name: &str) -> bool {
+  let segments = name.split('.').collect_vec();
+  segments.len() > 1 && segments.into_iter().all(is_identifier)
+}
+
+fn is_identifier(name: &str) -> bool {
+  name
+    .chars()
+    .next()
+    .map_or(false, |c| c.is_alphabetic() || c == '_')
+    && name.chars().all(|c| c.is_alphanumeric() || c == '_')
+}
+
+/// Escapes the regex metacharacters of the flag name with a character class (e.g. `payments[.]staleFlag`).
+/// Unlike a backslash, a character class is left as-is in the string of a query, and in the grep heuristic.
+/// `^` and `\` cannot be escaped this way, their backslash is doubled for the string of the query.
+fn escape_flag_name(flag_name: &str) -> String {
+  flag_name
+    .chars()
+    .map(|c| match c {
+      '^' | '\\' => format!("\\\\{c}"),
+      '.' | '+' | '*' | '?' | '(' | ')' | '|' | '[' | ']' | '{' | '}' | '$' => format!("[{c}]"),
+      _ => c.to_string(),
+    })
+    .collect()
+}
+
+/// Parses the rename `old=new` into the pair (old name, new name), `None` if either name is not an identifier.
+fn parse_rename(rename: &str) -> Option<(String, String)> {
+  rename
+    .split_once('=')
+    .filter(|(old, new)| is_identifier(old) && is_identifier(new) && old != new)
+    .map(|(old, new)| (old.to_string(), new.to_string()))
+}
+
 /// Gets rule graph for PiranhaArguments
 ///   * Loads the language specific graphs
 ///   * Merges these with the user defined graphs
@@ -316,7 +851,7 @@ fn get_rule_graph(_arg: &PiranhaArguments) -> RuleGraph {
 
   let built_in_rules = RuleGraphBuilder::default()
     .edges(piranha_language.edges().clone().unwrap_or_default().edges)
-    .rules(piranha_language.rules().clone().unwrap_or_default().rules)
+    .rules(get_enabled_built_in_rules(_arg))
     .build();
 
   // TODO: Move to `PiranhaArgumentBuilder`'s _validate - https://github.com/uber/piranha/issues/387
@@ -334,6 +869,142 @@ fn get_rule_graph(_arg: &PiranhaArguments) -> RuleGraph {
   built_in_rules.merge(&user_defined_rules)
 }
 
+/// Gets the built-in rules for the language, except the ones disabled via `disabled_builtin_rules`.
+/// A rule is disabled if either its name or one of its groups is disabled.
+/// Note that the edges to (and from) a disabled rule are dropped too.
+/// The rules handling the error result are disabled, except the ones of the strategy `error_result_handling`.
+/// With `ok_result_handling`, they are all disabled, and so are the rules handling the `ok` result of the other strategies.
+/// The rules preserving the flag API calls are enabled with `keep_flag_calls`, the preserved call is replaced with `flag_call_replacement` (if any).
+/// The rules replacing the deleted branch of the conditionals are enabled with `deleted_branch_replacement`.
+/// The lint rules are included if `lint_uncleanable_patterns` (or `strict`) is enabled.
+/// The enforcement rules are included if `removed_flags` are specified.
+/// The remnant rules are included if `scan_flag_remnants` is enabled.
+/// The rules stripping the provenance comments are included if `strip_provenance_comments` is enabled.
+fn get_enabled_built_in_rules(_arg: &PiranhaArguments) -> Vec<Rule> {
+  let mut built_in_rules = _arg.language().rules().clone().unwrap_or_default().rules;
+  if *_arg.lint_uncleanable_patterns() || *_arg.strict() {
+    match _arg.language().lint_rules() {
+      Some(lint_rules) => built_in_rules.extend(lint_rules.rules),
+      None => warn!("No lint rules for the language : {}", _arg.get_language()),
+    }
+  }
+  if !_arg.removed_flags().is_empty() {
+    match _arg.language().enforcement_rules() {
+      Some(enforcement_rules) => built_in_rules.extend(enforcement_rules.rules),
+      None => warn!(
+        "No enforcement rules for the language : {}",
+        _arg.get_language()
+      ),
+    }
+  }
+  if *_arg.scan_flag_remnants() {
+    match _arg.language().remnant_rules() {
+      Some(remnant_rules) => built_in_rules.extend(remnant_rules.rules),
+      None => warn!(
+        "No remnant rules for the language : {}",
+        _arg.get_language()
+      ),
+    }
+  }
+  if let Some(path) = _arg.path_to_config_values() {
+    match _arg.language().config_value_rules() {
+      // The fields of each value are substituted as a regex, the rules without any field are left out
+      Some(config_value_rules) => {
+        let config_values = read_config_values(path).unwrap_or_default();
+        let fields_with_value = |value: bool| {
+          config_values
+            .iter()
+            .filter(|(_, v)| **v == value)
+            .map(|(f, _)| escape_flag_name(f))
+            .sorted()
+            .join("|")
+        };
+        let substitutions = HashMap::from([
+          (CONFIG_TRUE_FIELDS.to_string(), fields_with_value(true)),
+          (CONFIG_FALSE_FIELDS.to_string(), fields_with_value(false)),
+        ]);
+        built_in_rules.extend(
+          config_value_rules
+            .rules
+            .iter()
+            .filter(|r| r.holes().iter().all(|h| !substitutions[h].is_empty()))
+            .map(|r| r.fill_holes(&substitutions)),
+        )
+      }
+      None => warn!(
+        "No config value rules for the language : {}",
+        _arg.get_language()
+      ),
+    }
+  }
+  if *_arg.strip_provenance_comments() {
+    match _arg.language().provenance_rules() {
+      Some(provenance_rules) => built_in_rules.extend(provenance_rules.rules),
+      None => warn!(
+        "No provenance rules for the language : {}",
+        _arg.get_language()
+      ),
+    }
+  }
+  let mut disabled: HashSet<&String> = _arg.disabled_builtin_rules().iter().collect();
+  for name in &disabled {
+    if !built_in_rules
+      .iter()
+      .any(|r| r.name() == *name || r.groups().contains(*name))
+    {
+      warn!("Could not disable the unknown built-in rule (or group) : {name}");
+    }
+  }
+  // The rules of the other strategies handling the error result (or the `ok` result, for the flag APIs returning `(value, ok)`)
+  let other_result_groups = match _arg.ok_result_handling() {
+    Some(ok_result_handling) => ERROR_RESULT_HANDLING_STRATEGIES
+      .iter()
+      .map(|s| format!("error_result_{s}"))
+      .chain(
+        OK_RESULT_HANDLING_STRATEGIES
+          .iter()
+          .filter(|s| **s != ok_result_handling)
+          .map(|s| format!("ok_result_{s}")),
+      )
+      .collect_vec(),
+    None => ERROR_RESULT_HANDLING_STRATEGIES
+      .iter()
+      .filter(|s| **s != _arg.error_result_handling())
+      .map(|s| format!("error_result_{s}"))
+      .chain(
+        OK_RESULT_HANDLING_STRATEGIES
+          .iter()
+          .map(|s| format!("ok_result_{s}")),
+      )
+      .collect_vec(),
+  };
+  disabled.extend(other_result_groups.iter());
+  let keep_flag_call_group = KEEP_FLAG_CALL_GROUP.to_string();
+  if !*_arg.keep_flag_calls() {
+    disabled.insert(&keep_flag_call_group);
+  }
+  let deleted_branch_replacement_group = DELETED_BRANCH_REPLACEMENT_GROUP.to_string();
+  if _arg.deleted_branch_replacement().is_none() {
+    disabled.insert(&deleted_branch_replacement_group);
+  }
+  built_in_rules
+    .into_iter()
+    .filter(|r| !disabled.contains(r.name()) && !r.groups().iter().any(|g| disabled.contains(g)))
+    .map(|r| match _arg.flag_call_replacement() {
+      Some(replacement) if r.groups().contains(&keep_flag_call_group) => {
+        r.replace_hole(KEPT_FLAG_CALL, replacement)
+      }
+      _ => r,
+    })
+    .map(|r| match _arg.deleted_branch_replacement() {
+      Some(replacement) if r.groups().contains(&deleted_branch_replacement_group) => {
+        r.replace_hole(DELETED_BRANCH, replacement)
+      }
+      _ => r,
+    })
+    .collect_vec()
+}
+
 #[cfg(test)]
 #[path = "unit_tests/piranha_arguments_test.rs"]
 mod piranha_arguments_test;
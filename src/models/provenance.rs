@@ -0,0 +1,134 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::ops::Range;
+
+use itertools::Itertools;
+use tree_sitter::Parser;
+
+use super::{
+  default_configs::{PROVENANCE_COMMENT_MARKER, PROVENANCE_COMMENT_SUFFIX},
+  source_code_unit::SourceCodeUnit,
+};
+
+// Implements the annotation of the rewritten regions with a provenance comment (e.g. `// cleaned: staleFlag (piranha)`)
+impl SourceCodeUnit {
+  /// Updates the ranges of the rewritten regions after an edit replacing the bytes `start..old_end` (with the bytes `start..new_end`).
+  /// The regions overlapping (or adjacent to) the edit are merged with its replacement, the regions after it are shifted,
+  /// and the regions entirely deleted by the edit are dropped.
+  pub(crate) fn update_rewritten_ranges(&mut self, start: usize, old_end: usize, new_end: usize) {
+    let shift = |byte: usize| byte - old_end + new_end;
+
+    let mut replacement = start..new_end;
+    let mut rewritten_ranges = vec![];
+    for range in self.rewritten_ranges_mut().drain(..) {
+      if range.end < start {
+        rewritten_ranges.push(range);
+      } else if range.start > old_end {
+        rewritten_ranges.push(shift(range.start)..shift(range.end));
+      } else {
+        let end = if range.end > old_end {
+          shift(range.end)
+        } else {
+          new_end
+        };
+        replacement = replacement.start.min(range.start)..replacement.end.max(end);
+      }
+    }
+    if !replacement.is_empty() {
+      rewritten_ranges.push(replacement);
+    }
+    rewritten_ranges.sort_by_key(|r| r.start);
+    *self.rewritten_ranges_mut() = rewritten_ranges;
+  }
+
+  /// Annotates each rewritten region that survives the cleanup with the provenance comment for `flag`, appended to its last line
+  /// (the comment is then part of the region).
+  /// A line ending within a comment or a (multi-line) string literal cannot be annotated, the previous line of the region is annotated instead.
+  /// A region already annotated (e.g. by a previous run) is not annotated again.
+  pub(crate) fn annotate_provenance(&mut self, flag: &str, parser: &mut Parser) {
+    let prefix = self.piranha_arguments().language().line_comment_prefix();
+    let comment =
+      format!("{prefix} {PROVENANCE_COMMENT_MARKER} {flag} {PROVENANCE_COMMENT_SUFFIX}");
+
+    let lines = self.code().split('\n').collect::<Vec<&str>>();
+    // The (byte) range of each line, excluding the line break
+    let line_ranges = lines
+      .iter()
+      .scan(0, |offset, line| {
+        let range = *offset..*offset + line.len();
+        *offset = range.end + 1;
+        Some(range)
+      })
+      .collect::<Vec<Range<usize>>>();
+    let line_of = |byte: usize| line_ranges.partition_point(|r| r.end < byte);
+
+    let mut annotated_lines = vec![];
+    for range in self.rewritten_ranges() {
+      let region = line_of(range.start)..=line_of(range.end - 1);
+      if region
+        .clone()
+        .any(|l| lines[l].contains(PROVENANCE_COMMENT_SUFFIX) || annotated_lines.contains(&l))
+      {
+        continue;
+      }
+      let last_line = region.rev().find(|l| {
+        !lines[*l].trim().is_empty() && self.can_end_with_comment(line_ranges[*l].end, lines[*l])
+      });
+      annotated_lines.extend(last_line);
+    }
+    if annotated_lines.is_empty() {
+      return;
+    }
+
+    // Appends the comment to the annotated lines (replacing their trailing whitespace), from the last one
+    let mut annotated_code = self.code().to_string();
+    let insertions = annotated_lines
+      .iter()
+      .sorted()
+      .rev()
+      .map(|l| {
+        (
+          line_ranges[*l].start + lines[*l].trim_end().len(),
+          line_ranges[*l].end,
+        )
+      })
+      .collect_vec();
+    for (start, old_end) in insertions {
+      annotated_code.replace_range(start..old_end, &format!(" {comment}"));
+      self.update_rewritten_ranges(start, old_end, start + comment.len() + 1);
+      self.update_original_error_ranges(start, old_end, start + comment.len() + 1);
+    }
+    self._replace_file_contents_and_re_parse(&annotated_code, parser, false);
+  }
+
+  /// Checks if a comment can be appended to the `line` ending at the byte `line_end`,
+  /// i.e. the line does not end within a comment, nor within a (multi-line) string literal.
+  fn can_end_with_comment(&self, line_end: usize, line: &str) -> bool {
+    let root = self.root_node();
+    let last_byte = line_end - (line.len() - line.trim_end().len()) - 1;
+    let ends_in_comment = root
+      .descendant_for_byte_range(last_byte, last_byte + 1)
+      .map_or(false, |n| n.kind().contains("comment"));
+    let ends_in_string = root
+      .descendant_for_byte_range(line_end, line_end + 1)
+      .map_or(false, |n| {
+        n.start_byte() < line_end && n.kind().contains("string")
+      });
+    !ends_in_comment && !ends_in_string
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/provenance_test.rs"]
+mod provenance_test;
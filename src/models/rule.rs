@@ -17,6 +17,7 @@ use colored::Colorize;
 use derive_builder::Builder;
 use getset::Getters;
 use pyo3::prelude::{pyclass, pymethods};
+use regex::Regex;
 use serde_derive::Deserialize;
 
 use crate::utilities::{gen_py_str_methods, tree_sitter_utilities::TSQuery, Instantiate};
@@ -98,6 +99,36 @@ impl Rule {
   pub(crate) fn is_match_only_rule(&self) -> bool {
     *self.query() != default_query() && *self.replace_node() == default_replace_node()
   }
+
+  /// Returns a copy of the rule, where the hole `hole` of the replacement pattern is replaced with `pattern`.
+  /// The tags referenced by `pattern` become the holes of the rule (in place of `hole`).
+  pub(crate) fn replace_hole(&self, hole: &str, pattern: &str) -> Rule {
+    let mut holes = self.holes().clone();
+    holes.remove(hole);
+    holes.extend(
+      Regex::new(r"@([a-zA-Z_][\w.]*)")
+        .unwrap()
+        .captures_iter(pattern)
+        .map(|c| c[1].to_string()),
+    );
+    let substitution = HashMap::from([(hole.to_string(), pattern.to_string())]);
+    Rule {
+      replace: self.replace().instantiate(&substitution),
+      holes,
+      ..self.clone()
+    }
+  }
+
+  /// Returns a copy of the rule, where the holes `substitutions_for_holes` are instantiated in both the query and the replacement pattern.
+  /// The other holes remain holes of the rule.
+  pub(crate) fn fill_holes(&self, substitutions_for_holes: &HashMap<String, String>) -> Rule {
+    let mut holes = self.holes().clone();
+    holes.retain(|h| !substitutions_for_holes.contains_key(h));
+    Rule {
+      holes,
+      ..self.instantiate(substitutions_for_holes)
+    }
+  }
 }
 
 #[macro_export]
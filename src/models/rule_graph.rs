@@ -30,6 +30,7 @@ use pyo3::prelude::{pyclass, pymethods};
 
 pub(crate) static GLOBAL: &str = "Global";
 pub(crate) static PARENT: &str = "Parent";
+pub(crate) static PACKAGE: &str = "Package";
 
 #[derive(Debug, Default, Getters, MutGetters, Builder, Clone, PartialEq)]
 #[builder(build_fn(name = "create"))]
@@ -172,7 +173,7 @@ impl RuleGraph {
       }
     }
     // Add empty entry, incase no next rule was found for a particular scope
-    for scope in [PARENT, GLOBAL] {
+    for scope in [PARENT, GLOBAL, PACKAGE] {
       next_rules.entry(scope.to_string()).or_default();
     }
     next_rules
@@ -22,12 +22,15 @@ use itertools::Itertools;
 use jwalk::WalkDir;
 use log::{debug, trace};
 use regex::Regex;
-use tree_sitter::Query;
+use tree_sitter::{Query, Tree};
 
 use crate::{
   models::piranha_arguments::PiranhaArguments,
   models::scopes::ScopeQueryGenerator,
-  utilities::{read_file, tree_sitter_utilities::TSQuery},
+  utilities::{
+    read_file,
+    tree_sitter_utilities::{get_match_for_query, TSQuery, EVAL_EQ_PREDICATE},
+  },
 };
 
 use super::{language::PiranhaLanguage, rule::InstantiatedRule};
@@ -41,9 +44,17 @@ pub(crate) struct RuleStore {
   // Current global rules to be applied.
   #[get = "pub"]
   global_rules: Vec<InstantiatedRule>,
+  // Current package rules to be applied, by package (i.e. the directory of the files they apply to).
+  #[get = "pub"]
+  package_rules: HashMap<PathBuf, Vec<InstantiatedRule>>,
 
   #[get = "pub"]
   language: PiranhaLanguage,
+
+  // Caches the (parsed) files of each package (i.e. the files of a directory).
+  package_files_cache: HashMap<PathBuf, Vec<(PathBuf, String, Tree)>>,
+  // The current content of the files processed so far.
+  processed_files: HashMap<PathBuf, String>,
 }
 
 impl RuleStore {
@@ -74,6 +85,23 @@ impl RuleStore {
     }
   }
 
+  /// Add a new package rule for the `package` (i.e. the directory of the files it applies to) (If it doesn't already exist)
+  pub(crate) fn add_to_package_rules(&mut self, package: &Path, rule: &InstantiatedRule) {
+    let package_rules = self.package_rules.entry(package.to_path_buf()).or_default();
+    if !package_rules.iter().any(|r| {
+      r.name().eq(&rule.name()) && r.replace().eq(&rule.replace()) && r.query().eq(&rule.query())
+    }) {
+      #[rustfmt::skip]
+      debug!("{}", format!("Added Package Rule : {:?} - {} for {:?}", rule.name(), rule.query().get_query(), package).bright_blue());
+      package_rules.push(rule.clone());
+    }
+  }
+
+  /// The number of package rules (of all the packages).
+  pub(crate) fn number_of_package_rules(&self) -> usize {
+    self.package_rules.values().map(Vec::len).sum()
+  }
+
   /// Get the compiled query for the `query_str` from the cache
   /// else compile it, add it to the cache and return it.
   pub(crate) fn query(&mut self, query_str: &TSQuery) -> &Query {
@@ -83,6 +111,77 @@ impl RuleStore {
       .or_insert_with(|| self.language.create_query(query_str.get_query()))
   }
 
+  /// Checks if any of the `queries` matches one of the other files of the package of `path` (i.e. the files of the same directory
+  /// the language can parse), in their current state. The files not processed yet (see `update_package_file`) are read from the disk.
+  pub(crate) fn matches_package_files(&mut self, path: &Path, queries: &[TSQuery]) -> bool {
+    let package = path.parent().map(Path::to_path_buf).unwrap_or_default();
+    self.cache_package_files(&package);
+    let package_files = &self.package_files_cache[&package];
+    for query_str in queries {
+      let query = self
+        .rule_query_cache
+        .entry(query_str.get_query())
+        .or_insert_with(|| self.language.create_query(query_str.get_query()));
+      if package_files.iter().any(|(p, code, tree)| {
+        p.file_name() != path.file_name()
+          && get_match_for_query(&tree.root_node(), code, query, true).is_some()
+      }) {
+        return true;
+      }
+    }
+    false
+  }
+
+  /// Parses the files of the `package` (if not cached yet) and adds them to the cache.
+  fn cache_package_files(&mut self, package: &Path) {
+    if self.package_files_cache.contains_key(package) {
+      return;
+    }
+    let mut parser = self.language.parser();
+    let package_files = WalkDir::new(package)
+      .max_depth(1)
+      .into_iter()
+      .filter_map(|e| e.ok())
+      .filter(|de| de.file_type().is_file() && self.language.can_parse(de))
+      .map(|de| de.path())
+      .sorted()
+      .filter_map(|p| {
+        self
+          .processed_files
+          .get(&p)
+          .cloned()
+          .or_else(|| read_file(&p).ok())
+          .map(|code| (p, code))
+      })
+      .filter_map(|(p, code)| parser.parse(&code, None).map(|tree| (p, code, tree)))
+      .collect_vec();
+    self
+      .package_files_cache
+      .insert(package.to_path_buf(), package_files);
+  }
+
+  /// Records the current content of the processed file `path`, so that the constraints checking the other files
+  /// of its package (i.e. `package_queries`) consider its edits.
+  pub(crate) fn update_package_file(&mut self, path: &Path, code: &str) {
+    if self.processed_files.get(path).map_or(false, |c| c.eq(code)) {
+      return;
+    }
+    self
+      .processed_files
+      .insert(path.to_path_buf(), code.to_string());
+    let package = path.parent().map(Path::to_path_buf).unwrap_or_default();
+    if let Some(package_files) = self.package_files_cache.get_mut(&package) {
+      for (p, c, tree) in package_files.iter_mut() {
+        if p.file_name() == path.file_name() {
+          if let Some(t) = self.language.parser().parse(code, None) {
+            *c = code.to_string();
+            *tree = t;
+          }
+        }
+      }
+    }
+  }
+
   // For the given scope level, get the ScopeQueryGenerator from the `scope_config.toml` file
   pub(crate) fn get_scope_query_generators(&self, scope_level: &str) -> Vec<ScopeQueryGenerator> {
     self
@@ -124,15 +223,60 @@ impl RuleStore {
     self.global_rules().iter().any(|x| !x.holes().is_empty())
   }
 
+  /// Checks if any global rule evaluates constant expressions (i.e. `#eval-eq?`).
+  /// The values of its holes may not appear as-is in the relevant files (e.g. `prefix + "staleFlag"`).
+  pub(crate) fn any_global_rules_evaluates_constants(&self) -> bool {
+    self
+      .global_rules()
+      .iter()
+      .any(|x| x.query().get_query().contains(EVAL_EQ_PREDICATE))
+  }
+
   /// Gets all the files from the code base that (i) have the language appropriate file extension, and (ii) contains the grep pattern.
   /// Note that `WalkDir` traverses the directory with parallelism.
   /// If all the global rules have no holes (i.e. we will have no grep patterns), we will try to find a match for each global rule in every file in the target.
+  /// The same applies when a global rule evaluates constant expressions.
+  /// The files of the packages with package rules are always analyzed.
   pub(crate) fn get_relevant_files(
     &self, path_to_codebase: &str, include: &Vec<Pattern>, exclude: &Vec<Pattern>,
   ) -> HashMap<PathBuf, String> {
-    let _path_to_codebase = Path::new(path_to_codebase).to_path_buf();
+    let mut files = self.get_files(path_to_codebase, include, exclude);
 
     //If the path_to_codebase is a file, then execute piranha on it
+    if Path::new(path_to_codebase).is_file() {
+      return files;
+    }
+
+    if self.any_global_rules_has_holes() && !self.any_global_rules_evaluates_constants() {
+      let pattern = self.get_grep_heuristics();
+      files = files
+        .iter()
+        // Filter the files containing the desired regex pattern
+        .filter(|x| pattern.is_match(x.1.as_str()) || self.has_package_rules(x.0))
+        .map(|(x, y)| (x.clone(), y.clone()))
+        .collect();
+    }
+    debug!(
+      "{}",
+      format!("{} files will be analyzed.", files.len()).green()
+    );
+    files
+  }
+
+  /// Checks if there are package rules for the package (i.e. the directory) of `path`.
+  fn has_package_rules(&self, path: &Path) -> bool {
+    path
+      .parent()
+      .and_then(|package| self.package_rules.get(package))
+      .map_or(false, |rules| !rules.is_empty())
+  }
+
+  /// Gets all the files from the code base that have the language appropriate file extension (along with their content).
+  pub(crate) fn get_files(
+    &self, path_to_codebase: &str, include: &Vec<Pattern>, exclude: &Vec<Pattern>,
+  ) -> HashMap<PathBuf, String> {
+    let _path_to_codebase = Path::new(path_to_codebase).to_path_buf();
+
     if _path_to_codebase.is_file() {
       return HashMap::from_iter([(
         _path_to_codebase.clone(),
@@ -140,7 +284,7 @@ impl RuleStore {
       )]);
     }
 
-    let mut files: HashMap<PathBuf, String> = WalkDir::new(path_to_codebase)
+    WalkDir::new(path_to_codebase)
       // walk over the entire code base
       .into_iter()
       // ignore errors
@@ -153,21 +297,6 @@ impl RuleStore {
       .filter(|de| self.language().can_parse(de))
       // read the file
       .map(|f| (f.path(), read_file(&f.path()).unwrap()))
-      .collect();
-
-    if self.any_global_rules_has_holes() {
-      let pattern = self.get_grep_heuristics();
-      files = files
-        .iter()
-        // Filter the files containing the desired regex pattern
-        .filter(|x| pattern.is_match(x.1.as_str()))
-        .map(|(x, y)| (x.clone(), y.clone()))
-        .collect();
-    }
-    debug!(
-      "{}",
-      format!("{} files will be analyzed.", files.len()).green()
-    );
-    files
+      .collect()
   }
 }
@@ -49,9 +49,11 @@ pub(crate) struct ScopeQueryGenerator {
 impl SourceCodeUnit {
   /// Generate a tree-sitter based query representing the scope of the previous edit.
   /// We generate these scope queries by matching the rules provided in `<lang>_scopes.toml`.
-  pub(crate) fn get_scope_query(
+  /// Returns `None` if the previous edit is not enclosed in a `scope_level` scope
+  /// (e.g. a package level declaration has no enclosing function).
+  pub(crate) fn get_enclosing_scope_query(
     &self, scope_level: &str, start_byte: usize, end_byte: usize, rules_store: &mut RuleStore,
-  ) -> TSQuery {
+  ) -> Option<TSQuery> {
     let root_node = self.root_node();
     let mut changed_node = get_node_for_range(root_node, start_byte, end_byte);
     // Get the scope matchers for `scope_level` from the `scope_config.toml`.
@@ -73,7 +75,7 @@ impl SourceCodeUnit {
         ) {
           // Generate the scope query for the specific context by substituting the
           // the tags with code snippets appropriately in the `generator` query.
-          return m.generator().instantiate(p_match.matches());
+          return Some(m.generator().instantiate(p_match.matches()));
         }
       }
       if let Some(parent) = changed_node.parent() {
@@ -82,7 +84,7 @@ impl SourceCodeUnit {
         break;
       }
     }
-    panic!("Could not create scope query for {scope_level:?}");
+    None
   }
 }
 
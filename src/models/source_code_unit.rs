@@ -17,21 +17,26 @@ use std::{
 
 use colored::Colorize;
 use itertools::Itertools;
-use log::{debug, error};
+use log::{debug, error, info, warn};
 
-use tree_sitter::{InputEdit, Node, Parser, Range, Tree};
+use tree_sitter::{InputEdit, Node, Parser, Point, Range, Tree};
 use tree_sitter_traversal::{traverse, Order};
 
 use crate::{
-  models::rule_graph::{GLOBAL, PARENT},
+  models::rule_graph::{GLOBAL, PACKAGE, PARENT},
   utilities::tree_sitter_utilities::{
     get_match_for_query, get_node_for_range, get_replace_range, get_tree_sitter_edit, TSQuery,
   },
 };
 
 use super::{
-  edit::Edit, matches::Match, piranha_arguments::PiranhaArguments, rule::InstantiatedRule,
+  default_configs::{DISABLE_FILE_DIRECTIVE, MAX_CASCADE_DEPTH},
+  edit::Edit,
+  matches::Match,
+  piranha_arguments::PiranhaArguments,
+  rule::InstantiatedRule,
   rule_store::RuleStore,
+  unsupported_syntax::SkippedFunction,
 };
 use getset::{CopyGetters, Getters, MutGetters, Setters};
 // Maintains the updated source code content and AST of the file
@@ -59,13 +64,46 @@ pub(crate) struct SourceCodeUnit {
   #[get = "pub"]
   #[get_mut = "pub"]
   rewrites: Vec<Edit>,
+  // The flag (if any) captured by the seed match whose cascade produced each rewrite (see `Match::get_flag_name`)
+  #[get = "pub"]
+  rewrite_flags: Vec<Option<String>>,
+  // The flag captured by the seed match currently being propagated
+  current_flag: Option<String>,
   // Matches for the read_only rules in this source code unit
   #[get = "pub"]
   #[get_mut = "pub"]
   matches: Vec<(String, Match)>,
+  // The (byte) ranges of the regions rewritten in this source code unit, i.e. the replacements surviving the later rewrites
+  #[get = "pub"]
+  #[get_mut = "pub"]
+  rewritten_ranges: Vec<std::ops::Range<usize>>,
   // Piranha Arguments passed by the user
   #[get = "pub"]
   piranha_arguments: PiranhaArguments,
+  // Whether the file opts out of the rewrites (via the `piranha:disable-file` directive).
+  // The rewrite rules are then applied as "match-only" rules, i.e. their usages are only reported.
+  #[get = "pub"]
+  rewrites_disabled: bool,
+  // The number of cascades of scoped (e.g. `Function-Method`) rules currently being applied within each other
+  cascade_depth: usize,
+  // Whether a cascade was cut off, since it was nested deeper than `MAX_CASCADE_DEPTH`
+  #[get = "pub"]
+  cascade_truncated: bool,
+  // The functions skipped since they use a syntax the grammar does not support (e.g. a syntax newer than the bundled grammar).
+  // Their code is neither matched nor rewritten, while the rest of the file is cleaned up.
+  #[get = "pub"]
+  skipped_functions: Vec<SkippedFunction>,
+  // The (byte) ranges of the syntax errors of the original content (with `allow_dirty_ast`), updated after each edit.
+  // The edits overlapping an error drop it, i.e. the errors re-parsed within the edited regions are introduced by the edits.
+  original_error_ranges: Vec<std::ops::Range<usize>>,
+  // The (byte) ranges of the flag API calls preserved by the edits (see `Edit::kept_call_range`), updated after each edit.
+  // These calls are not rewritten again.
+  #[get = "pub"]
+  kept_call_ranges: Vec<std::ops::Range<usize>>,
+  // The position of the first syntax error introduced by an edit, if any.
+  // The file is not rewritten any further, its edits are rolled back once the cleanup is finished.
+  #[get = "pub"]
+  introduced_syntax_error: Option<Point>,
 }
 
 impl SourceCodeUnit {
@@ -74,25 +112,76 @@ impl SourceCodeUnit {
     piranha_arguments: &PiranhaArguments,
   ) -> Self {
     let ast = parser.parse(&code, None).expect("Could not parse code");
-    let source_code_unit = Self {
+    let mut source_code_unit = Self {
       ast,
       original_content: code.to_string(),
       code,
       substitutions: substitutions.clone(),
       path: path.to_path_buf(),
       rewrites: Vec::new(),
+      rewrite_flags: Vec::new(),
+      current_flag: None,
       matches: Vec::new(),
+      rewritten_ranges: Vec::new(),
       piranha_arguments: piranha_arguments.clone(),
+      rewrites_disabled: false,
+      cascade_depth: 0,
+      cascade_truncated: false,
+      skipped_functions: Vec::new(),
+      original_error_ranges: Vec::new(),
+      kept_call_ranges: Vec::new(),
+      introduced_syntax_error: None,
     };
-    // Panic if allow dirty ast is false and the tree is syntactically incorrect
+    source_code_unit.original_error_ranges = get_error_nodes(&source_code_unit.root_node())
+      .iter()
+      .map(|node| node.start_byte()..node.end_byte())
+      .collect_vec();
+    // Unless allow dirty ast is true, skip the functions using the `unsupported_syntax` constructs (if any),
+    // and panic if the tree has any other syntax error
     if !piranha_arguments.allow_dirty_ast() && source_code_unit._number_of_errors() > 0 {
-      error!("{}: {}", "Syntax Error".red(), path.to_str().unwrap().red());
-      _ = &source_code_unit._panic_for_syntax_error();
+      match source_code_unit.find_unsupported_functions() {
+        Some(skipped_functions) => {
+          for f in &skipped_functions {
+            #[rustfmt::skip]
+            warn!("Skipping the function {} of {:?}, since the grammar does not support its syntax ({})", f.name(), path, f.reason());
+          }
+          source_code_unit.skipped_functions = skipped_functions;
+        }
+        None => {
+          error!("{}: {}", "Syntax Error".red(), path.to_str().unwrap().red());
+          _ = &source_code_unit._panic_for_syntax_error();
+        }
+      }
     }
+    source_code_unit.rewrites_disabled = source_code_unit.has_disable_file_directive();
 
     source_code_unit
   }
 
+  /// Checks if the comments at the top of the file (e.g. before the `package` clause in Go)
+  /// contain the directive `piranha:disable-file` (e.g. `// piranha:disable-file`).
+  fn has_disable_file_directive(&self) -> bool {
+    let language = self.piranha_arguments.language();
+    let root_node = self.root_node();
+    let mut cursor = root_node.walk();
+    let directive = root_node
+      .named_children(&mut cursor)
+      .take_while(|n| language.comment_nodes().contains(&n.kind().to_string()))
+      .flat_map(|n| n.utf8_text(self.code.as_bytes()).ok())
+      .any(|comment| {
+        comment
+          .strip_prefix(language.line_comment_prefix())
+          .map_or(false, |c| c.trim() == DISABLE_FILE_DIRECTIVE)
+      });
+    if directive {
+      info!(
+        "Rewrites are disabled (via `{DISABLE_FILE_DIRECTIVE}`) for {:?}",
+        self.path
+      );
+    }
+    directive
+  }
+
   pub(crate) fn root_node(&self) -> Node<'_> {
     self.ast.root_node()
   }
@@ -132,6 +221,10 @@ impl SourceCodeUnit {
     &mut self, rule: InstantiatedRule, rule_store: &mut RuleStore, parser: &mut Parser,
     scope_query: &Option<TSQuery>,
   ) -> bool {
+    // The file is not rewritten (nor matched) any further once an edit introduced a syntax error
+    if self.introduced_syntax_error.is_some() {
+      return false;
+    }
     let scope_node = self.get_scope_node(scope_query, rule_store);
 
     let mut query_again = false;
@@ -140,9 +233,13 @@ impl SourceCodeUnit {
     // Update the first match of the rewrite rule
     // Add mappings to the substitution
     // Propagate each applied edit. The next rule will be applied relative to the application of this edit.
-    if !rule.rule().is_match_only_rule() {
+    if !rule.rule().is_match_only_rule() && !self.rewrites_disabled {
       if let Some(edit) = self.get_edit(&rule, rule_store, scope_node, true) {
-        self.rewrites_mut().push(edit.clone());
+        // The rules applied outside of any cascade are the seed rules
+        if self.cascade_depth == 0 {
+          self.current_flag = edit.p_match().get_flag_name();
+        }
+        self.push_rewrite(edit.clone());
         query_again = true;
 
         // Add all the (code_snippet, tag) mapping to the substitution table.
@@ -154,7 +251,7 @@ impl SourceCodeUnit {
         self.propagate(get_replace_range(applied_ts_edit), rule, rule_store, parser);
       }
     }
-    // When rule is a "match-only" rule :
+    // When rule is a "match-only" rule (or the rewrites are disabled for the file) :
     // Get all the matches
     // Add mappings to the substitution
     // Propagate each match. Note that,  we pass a identity edit (where old range == new range) in to the propagate logic.
@@ -162,6 +259,9 @@ impl SourceCodeUnit {
     else {
       for m in self.get_matches(&rule, rule_store, scope_node, true) {
         self.matches_mut().push((rule.name(), m.clone()));
+        if self.cascade_depth == 0 {
+          self.current_flag = m.get_flag_name();
+        }
 
         // In this scenario we pass the match and replace range as the range of the match `m`
         // This is equivalent to propagating an identity rule
@@ -186,9 +286,10 @@ impl SourceCodeUnit {
   /// Algorithm:
   ///
   /// (i) Lookup the `rule_store` and get all the (next) rules that could be after applying the current rule (`rule`).
-  ///   * We will receive the rules grouped by scope:  `GLOBAL` and `PARENT` are applicable to each language. However, other scopes are determined
+  ///   * We will receive the rules grouped by scope:  `GLOBAL`, `PACKAGE` and `PARENT` are applicable to each language. However, other scopes are determined
   ///     based on the `<language>/scope_config.toml`.
-  /// (ii) Add the `GLOBAL` rule to the global rule list in the `rule_store` (This will be performed in the next iteration)
+  /// (ii) Add the `GLOBAL` rule to the global rule list in the `rule_store` (This will be performed in the next iteration),
+  ///      and the `PACKAGE` rule to the rules of the package (i.e. the directory) of this file
   /// (iii) Apply the local cleanup i.e. `PARENT` scoped rules
   ///  (iv) Go to step 1 (and repeat this for the applicable parent scoped rule. Do this until, no parent scoped rule is applicable.) (recursive)
   ///  (iv) Apply the rules based on custom language specific scopes (as defined in `<language>/scope_config.toml`) (recursive)
@@ -197,6 +298,9 @@ impl SourceCodeUnit {
     &mut self, replace_range: Range, rule: InstantiatedRule, rules_store: &mut RuleStore,
     parser: &mut Parser,
   ) {
+    if self.introduced_syntax_error.is_some() {
+      return;
+    }
     let mut current_replace_range = replace_range;
 
     let mut current_rule = rule.name();
@@ -221,7 +325,7 @@ impl SourceCodeUnit {
           .join("\n")
       );
 
-      // Adds rules of scope != ["Parent", "Global"] to the stack
+      // Adds rules of scope != ["Parent", "Global", "Package"] to the stack
       self.add_rules_to_stack(
         &next_rules_by_scope,
         current_replace_range,
@@ -234,6 +338,12 @@ impl SourceCodeUnit {
         rules_store.add_to_global_rules(r);
       }
 
+      // Add Package rules as seed rules of the files of this package
+      let package = self.path.parent().unwrap_or_else(|| Path::new(""));
+      for r in &next_rules_by_scope[PACKAGE] {
+        rules_store.add_to_package_rules(package, r);
+      }
+
       // Process the parent
       // Find the rules to be applied in the "Parent" scope that match any parent (context) of the changed node in the previous edit
       if let Some(edit) = self.get_edit_for_context(
@@ -242,7 +352,7 @@ impl SourceCodeUnit {
         rules_store,
         &next_rules_by_scope[PARENT],
       ) {
-        self.rewrites_mut().push(edit.clone());
+        self.push_rewrite(edit.clone());
         debug!(
           "\n{}",
           format!(
@@ -253,6 +363,9 @@ impl SourceCodeUnit {
         );
         // Apply the matched rule to the parent
         let applied_edit = self.apply_edit(&edit, parser);
+        if self.introduced_syntax_error.is_some() {
+          return;
+        }
         current_replace_range = get_replace_range(applied_edit);
         current_rule = edit.matched_rule().to_string();
         // Add the (tag, code_snippet) mapping to substitution table.
@@ -263,10 +376,32 @@ impl SourceCodeUnit {
       }
     }
 
+    // The next rules are applied recursively, hence the cascades nested deeper than `MAX_CASCADE_DEPTH`
+    // (e.g. in generated code) are cut off, instead of overflowing the stack.
+    if !next_rules_stack.is_empty() && self.cascade_depth >= MAX_CASCADE_DEPTH {
+      if !self.cascade_truncated {
+        #[rustfmt::skip]
+        warn!("Stopped cleaning up {:?} after {} nested cascades of rules (after `{}`). The cleanup of this file may be incomplete.", self.path, MAX_CASCADE_DEPTH, current_rule);
+      }
+      self.cascade_truncated = true;
+      return;
+    }
     // Apply the next rules from the stack
+    self.cascade_depth += 1;
     for (sq, rle) in &next_rules_stack {
       self.apply_rule(rle.clone(), rules_store, parser, &Some(sq.clone()));
     }
+    self.cascade_depth -= 1;
+  }
+
+  /// Records the rewrite, along with the flag of the cascade it belongs to.
+  /// The no-op rewrites (that only trigger their next rules) are not recorded.
+  fn push_rewrite(&mut self, edit: Edit) {
+    if edit.is_no_op() {
+      return;
+    }
+    self.rewrites.push(edit);
+    self.rewrite_flags.push(self.current_flag.clone());
   }
 
   /// Adds the "Method" and "Class" scoped next rules to the queue.
@@ -276,17 +411,25 @@ impl SourceCodeUnit {
     stack: &mut VecDeque<(TSQuery, InstantiatedRule)>,
   ) {
     for (scope_level, rules) in next_rules_by_scope {
-      // Scope level is not "PArent" or "Global"
-      if ![PARENT, GLOBAL].contains(&scope_level.as_str()) {
+      // Scope level is not "PArent", "Global" or "Package"
+      if ![PARENT, GLOBAL, PACKAGE].contains(&scope_level.as_str()) {
         for rule in rules {
-          let scope_query = self.get_scope_query(
+          if let Some(scope_query) = self.get_enclosing_scope_query(
             scope_level,
             current_match_range.start_byte,
             current_match_range.end_byte,
             rules_store,
-          );
-          // Add Method and Class scoped rules to the queue
-          stack.push_front((scope_query, rule.clone()));
+          ) {
+            // Add Method and Class scoped rules to the queue
+            stack.push_front((scope_query, rule.clone()));
+          } else {
+            // The edit is not enclosed in a `scope_level` scope (e.g. a package level declaration)
+            debug!(
+              "No enclosing {} scope, skipping {}",
+              scope_level,
+              rule.name()
+            );
+          }
         }
       }
     }
@@ -339,17 +482,61 @@ impl SourceCodeUnit {
     // Get the tree_sitter's input edit representation
     let (new_source_code, ts_edit) = get_tree_sitter_edit(self.code.clone(), edit);
     // Apply edit to the tree
-    let number_of_errors = self._number_of_errors();
     self.ast.edit(&ts_edit);
     self._replace_file_contents_and_re_parse(&new_source_code, parser, true);
+    self.update_rewritten_ranges(
+      ts_edit.start_byte,
+      ts_edit.old_end_byte,
+      ts_edit.new_end_byte,
+    );
+    self.update_original_error_ranges(
+      ts_edit.start_byte,
+      ts_edit.old_end_byte,
+      ts_edit.new_end_byte,
+    );
+    self.kept_call_ranges = shift_ranges(
+      &self.kept_call_ranges,
+      ts_edit.start_byte,
+      ts_edit.old_end_byte,
+      ts_edit.new_end_byte,
+    );
+    if let Some(range) = edit.kept_call_range() {
+      let start = ts_edit.start_byte;
+      self
+        .kept_call_ranges
+        .push(start + range.start..start + range.end);
+    }
 
-    // Panic if the number of errors increased after the edit
-    if self._number_of_errors() > number_of_errors {
-      self._panic_for_syntax_error();
+    // Stop rewriting the file if the edit introduced a syntax error, its edits are rolled back at the end of the cleanup
+    if let Some(position) = self.find_error_outside_original_errors(&self.root_node()) {
+      #[rustfmt::skip]
+      error!("The rule {} produced a syntax error at {}:{} in {:?}, the file will not be rewritten any further.", edit.matched_rule(), position.row + 1, position.column + 1, self.path());
+      self.introduced_syntax_error = Some(position);
     }
     ts_edit
   }
 
+  /// Updates the ranges of the original syntax errors after an edit replacing the bytes `start..old_end` (with the bytes `start..new_end`).
+  /// The errors after the edit are shifted, and the errors overlapping it are dropped.
+  pub(crate) fn update_original_error_ranges(
+    &mut self, start: usize, old_end: usize, new_end: usize,
+  ) {
+    self.original_error_ranges = shift_ranges(&self.original_error_ranges, start, old_end, new_end);
+  }
+
+  /// Returns the position of the first syntax error of the tree (rooted at `root`) whose range is not the range of an
+  /// original syntax error.
+  fn find_error_outside_original_errors(&self, root: &Node) -> Option<Point> {
+    get_error_nodes(root)
+      .iter()
+      .find(|node| {
+        !self
+          .original_error_ranges
+          .contains(&(node.start_byte()..node.end_byte()))
+      })
+      .map(|node| node.start_position())
+  }
+
   fn _panic_for_syntax_error(&self) {
     let msg = format!(
       "Produced syntactically incorrect source code {}",
@@ -360,9 +547,36 @@ impl SourceCodeUnit {
 
   /// Returns the number of errors in the AST
   fn _number_of_errors(&self) -> usize {
-    traverse(self.root_node().walk(), Order::Post)
-      .filter(|node| node.is_error() || node.is_missing())
-      .count()
+    get_error_nodes(&self.root_node()).len()
+  }
+
+  /// Re-parses the code from scratch (i.e. not incrementally), and returns the position of the first syntax error
+  /// if the edits introduced any (see `introduced_syntax_error`). The syntax errors of the original content
+  /// (with `allow_dirty_ast`) are tolerated, as long as their ranges (shifted by the edits) are unchanged.
+  pub(crate) fn find_introduced_syntax_error(&self, parser: &mut Parser) -> Option<Point> {
+    if self.introduced_syntax_error.is_some() {
+      return self.introduced_syntax_error;
+    }
+    let tree = parser
+      .parse(self.code(), None)
+      .expect("Could not parse code");
+    self.find_error_outside_original_errors(&tree.root_node())
+  }
+
+  /// Discards the rewrites of this source code unit, i.e. restores its original content.
+  /// The matches (e.g. of the lint rules) are kept.
+  pub(crate) fn roll_back(&mut self, parser: &mut Parser) {
+    let original_content = self.original_content().to_string();
+    self._replace_file_contents_and_re_parse(&original_content, parser, false);
+    self.rewrites.clear();
+    self.rewrite_flags.clear();
+    self.rewritten_ranges.clear();
+    self.kept_call_ranges.clear();
+    self.original_error_ranges = get_error_nodes(&self.root_node())
+      .iter()
+      .map(|node| node.start_byte()..node.end_byte())
+      .collect_vec();
+    self.introduced_syntax_error = None;
   }
 
   // Replaces the content of the current file with the new content and re-parses the AST
@@ -397,6 +611,32 @@ impl SourceCodeUnit {
   }
 }
 
+/// Returns the `ranges` after an edit replacing the bytes `start..old_end` (with the bytes `start..new_end`).
+/// The ranges after the edit are shifted, and the ranges overlapping it are dropped.
+fn shift_ranges(
+  ranges: &[std::ops::Range<usize>], start: usize, old_end: usize, new_end: usize,
+) -> Vec<std::ops::Range<usize>> {
+  ranges
+    .iter()
+    .filter_map(|range| {
+      if range.end <= start {
+        Some(range.clone())
+      } else if range.start >= old_end {
+        Some(range.start - old_end + new_end..range.end - old_end + new_end)
+      } else {
+        None
+      }
+    })
+    .collect_vec()
+}
+
+/// Returns the error (and missing) nodes of the tree rooted at `root`, in pre-order.
+pub(crate) fn get_error_nodes<'a>(root: &Node<'a>) -> Vec<Node<'a>> {
+  traverse(root.walk(), Order::Pre)
+    .filter(|node| node.is_error() || node.is_missing())
+    .collect_vec()
+}
+
 #[cfg(test)]
 #[path = "unit_tests/source_code_unit_test.rs"]
 mod source_code_unit_test;
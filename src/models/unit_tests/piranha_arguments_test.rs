@@ -11,8 +11,15 @@
  limitations under the License.
 */
 
+use std::fs;
+
+use tempdir::TempDir;
+
 use crate::{
-  models::{default_configs::JAVA, language::PiranhaLanguage},
+  models::{
+    default_configs::{GO, JAVA},
+    language::PiranhaLanguage,
+  },
   tests::substitutions,
 };
 
@@ -41,3 +48,176 @@ fn piranha_argument_invalid_both_codebase_and_snippet() {
     .substitutions(substitutions! {"super_interface_name" => "SomeInterface"})
     .build();
 }
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. Please specify the substitution `flag_api` (e.g. `BoolValue|StrValue`) when `lint_uncleanable_patterns` is enabled."
+)]
+fn piranha_argument_invalid_lint_without_flag_api() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .lint_uncleanable_patterns(true)
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `error_result_handling` should be one of [\"assume_nil\", \"preserve_call\", \"keep_handling\"], found `ignore`."
+)]
+fn piranha_argument_invalid_error_result_handling() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .error_result_handling("ignore".to_string())
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `flag_call_replacement` requires `keep_flag_calls` to be enabled."
+)]
+fn piranha_argument_invalid_flag_call_replacement_without_keep_flag_calls() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .flag_call_replacement(Some("exp.RecordExposure(@arg_str_literal)".to_string()))
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `dry_run_rules`, `dry_run_flags` and `dry_run_paths` require `dry_run` to be enabled."
+)]
+fn piranha_argument_invalid_dry_run_filter_without_dry_run() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .dry_run_rules(vec!["delete_statement_after_return".to_string()])
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. Please specify the substitution `flag_api` (e.g. `BoolValue|StrValue`) when `removed_flags` are specified."
+)]
+fn piranha_argument_invalid_removed_flags_without_flag_api() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .removed_flags(vec!["stale_flag".to_string()])
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `path_to_codeowners` and `patch_path_prefixes` require `path_to_patch`."
+)]
+fn piranha_argument_invalid_patch_split_without_path_to_patch() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .patch_path_prefixes(vec!["services/payments".to_string()])
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `renames` should be `old=new` pairs of identifiers, found `newCheckoutFlow`."
+)]
+fn piranha_argument_invalid_rename() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .renames(vec!["newCheckoutFlow".to_string()])
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. Please specify the substitution `flag_api` (e.g. `BoolValue|StrValue`) when `strict` is enabled."
+)]
+fn piranha_argument_strict_without_flag_api() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .strict(true)
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. Please specify the `path_to_checkpoint` when a `deadline` is set."
+)]
+fn piranha_argument_deadline_without_checkpoint() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .deadline(3600)
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The config values should be keyed by selectors (e.g. `cfg.Features.NewFlow`), found `NewFlow`."
+)]
+fn piranha_argument_invalid_config_value() {
+  let temp_dir = TempDir::new("config_values").unwrap();
+  let path = temp_dir.path().join("config_values.toml");
+  fs::write(&path, "\"NewFlow\" = true\n").unwrap();
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .path_to_config_values(Some(path.to_str().unwrap().to_string()))
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. Please specify the `removed_flags` when `scan_flag_remnants` is enabled."
+)]
+fn piranha_argument_scan_flag_remnants_without_removed_flags() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .scan_flag_remnants(true)
+    .build();
+}
+
+#[test]
+#[should_panic(
+  expected = "Invalid Piranha arguments. The `ok_result_handling` should be one of [\"assume_ok\", \"preserve_check\"], found `ignore`."
+)]
+fn piranha_argument_invalid_ok_result_handling() {
+  let _ = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .ok_result_handling(Some("ignore".to_string()))
+    .build();
+}
+
+#[test]
+fn piranha_argument_rename_rules() {
+  let args = PiranhaArgumentsBuilder::default()
+    .path_to_codebase("dev/null".to_string())
+    .language(PiranhaLanguage::from(GO))
+    .renames(vec![
+      "newCheckoutFlow=checkoutFlow".to_string(),
+      "handlerV2=handler".to_string(),
+    ])
+    .build();
+
+  assert_eq!(
+    args.get_renames(),
+    vec![
+      ("newCheckoutFlow".to_string(), "checkoutFlow".to_string()),
+      ("handlerV2".to_string(), "handler".to_string())
+    ]
+  );
+  // The rename rules are applied once the cleanup is complete (see `Piranha::perform_renames`)
+  assert!(!args
+    .rule_graph()
+    .rules()
+    .iter()
+    .any(|r| r.groups().contains("rename")));
+}
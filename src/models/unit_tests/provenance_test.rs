@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use tree_sitter::Parser;
+
+use crate::models::{
+  default_configs::GO, language::PiranhaLanguage, source_code_unit::SourceCodeUnit,
+};
+
+fn get_go_parser() -> Parser {
+  let mut parser = Parser::new();
+  parser
+    .set_language(*PiranhaLanguage::from(GO).language())
+    .unwrap();
+  parser
+}
+
+#[test]
+fn test_update_rewritten_ranges() {
+  let mut parser = get_go_parser();
+  let mut source_code_unit = SourceCodeUnit::default("package main\n", &mut parser, GO.to_string());
+  *source_code_unit.rewritten_ranges_mut() = vec![10..20, 30..40, 50..60];
+
+  // Replaces `15..35` (overlapping the first two regions) with 5 bytes
+  source_code_unit.update_rewritten_ranges(15, 35, 20);
+  assert_eq!(source_code_unit.rewritten_ranges(), &vec![10..25, 35..45]);
+
+  // Deletes the last region entirely
+  source_code_unit.update_rewritten_ranges(30, 50, 30);
+  assert_eq!(source_code_unit.rewritten_ranges(), &vec![10..25]);
+}
+
+#[test]
+fn test_annotate_provenance() {
+  let updated = "package main
+
+func main() {
+\tfmt.Println(\"enabled\")
+\tenabled := true // enabled
+\tquery := `
+\t\tenabled = ` + strconv.FormatBool(true) + `
+\t`
+\tfmt.Println(enabled, query)
+}
+";
+  let mut parser = get_go_parser();
+  let mut source_code_unit = SourceCodeUnit::default(updated, &mut parser, GO.to_string());
+  // The range of the `region` (right after the `prefix`)
+  let range_of = |prefix: &str, region: &str| {
+    let start = updated.find(&format!("{prefix}{region}")).unwrap() + prefix.len();
+    start..start + region.len()
+  };
+  *source_code_unit.rewritten_ranges_mut() = vec![
+    range_of("\t", "fmt.Println(\"enabled\")"),
+    range_of("enabled := ", "true"),
+    range_of("FormatBool(", "true"),
+  ];
+
+  source_code_unit.annotate_provenance("staleFlag", &mut parser);
+  // The lines ending with a comment, or within a raw string literal, are not annotated
+  let expected = "package main
+
+func main() {
+\tfmt.Println(\"enabled\") // cleaned: staleFlag (piranha)
+\tenabled := true // enabled
+\tquery := `
+\t\tenabled = ` + strconv.FormatBool(true) + `
+\t`
+\tfmt.Println(enabled, query)
+}
+";
+  assert_eq!(source_code_unit.code(), expected);
+
+  // The annotated region is not annotated again
+  source_code_unit.annotate_provenance("staleFlag", &mut parser);
+  assert_eq!(source_code_unit.code(), expected);
+}
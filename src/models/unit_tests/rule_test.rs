@@ -19,7 +19,7 @@ use crate::{
 use super::InstantiatedRule;
 use {
   crate::models::{rule_store::RuleStore, source_code_unit::SourceCodeUnit},
-  std::collections::HashMap,
+  std::collections::{HashMap, HashSet},
   std::path::PathBuf,
 };
 
@@ -265,3 +265,27 @@ fn test_get_edit_for_context_negative() {
   // let edit = rule.get_edit(&source_code_unit, &mut rule_store, node, true);
   assert!(edit.is_none());
 }
+
+/// Tests whether the hole of the replacement pattern is replaced (along with the holes of the rule).
+#[test]
+fn test_rule_replace_hole() {
+  let rule = piranha_rule! {
+    name= "test",
+    query= "(if_statement) @if_statement",
+    replace_node = "if_statement",
+    replace = "@call_exp",
+    holes = ["call_exp"]
+  };
+
+  let updated_rule = rule.replace_hole("call_exp", "exp.RecordExposure(@arg_str_literal)");
+
+  assert_eq!(
+    updated_rule.replace(),
+    "exp.RecordExposure(@arg_str_literal)"
+  );
+  assert_eq!(
+    updated_rule.holes(),
+    &HashSet::from(["arg_str_literal".to_string()])
+  );
+  assert_eq!(updated_rule.query(), rule.query());
+}
@@ -13,7 +13,7 @@
 
 use crate::{
   models::{
-    default_configs::JAVA,
+    default_configs::{GO, JAVA},
     language::PiranhaLanguage,
     piranha_arguments::{PiranhaArguments, PiranhaArgumentsBuilder},
   },
@@ -135,7 +135,9 @@ fn test_get_scope_query_positive() {
     &piranha_args,
   );
   let mut rule_store = RuleStore::new(&piranha_args);
-  let scope_query_method = source_code_unit.get_scope_query("Method", 133, 134, &mut rule_store);
+  let scope_query_method = source_code_unit
+    .get_enclosing_scope_query("Method", 133, 134, &mut rule_store)
+    .unwrap();
 
   println!("{}", scope_query_method.get_query().as_str());
   assert!(eq_without_whitespace(
@@ -157,7 +159,9 @@ fn test_get_scope_query_positive() {
     )@qdn"
   ));
 
-  let scope_query_class = source_code_unit.get_scope_query("Class", 133, 134, &mut rule_store);
+  let scope_query_class = source_code_unit
+    .get_enclosing_scope_query("Class", 133, 134, &mut rule_store)
+    .unwrap();
   assert!(eq_without_whitespace(
     scope_query_class.get_query().as_str(),
     "(
@@ -169,7 +173,6 @@ fn test_get_scope_query_positive() {
 
 /// Negative test for the generated scope query, given scope generators, source code and position of pervious edit.
 #[test]
-#[should_panic]
 fn test_get_scope_query_negative() {
   let source_code = "class Test {
       pub void foobar(int a, int b, int c, int d){
@@ -191,5 +194,51 @@ fn test_get_scope_query_negative() {
     &piranha_args,
   );
   let mut rule_store = RuleStore::new(&piranha_args);
-  let _ = source_code_unit.get_scope_query("Method", 9, 10, &mut rule_store);
+  assert!(source_code_unit
+    .get_enclosing_scope_query("Method", 9, 10, &mut rule_store)
+    .is_none());
+}
+
+/// The generated scope query for a Go method should include the receiver,
+/// so that methods with the same name and parameters (but different receivers) are not confused.
+#[test]
+fn test_get_scope_query_go_method_with_receiver() {
+  let source_code = "package main
+
+    func (s Server) run() {
+      enabled := true
+    }
+
+    func (c *Client) run() {
+      enabled := false
+    }";
+
+  let piranha_args = PiranhaArgumentsBuilder::default()
+    .language(PiranhaLanguage::from(GO))
+    .create()
+    .unwrap();
+  let mut parser = PiranhaLanguage::from(GO).parser();
+
+  let source_code_unit = SourceCodeUnit::new(
+    &mut parser,
+    source_code.to_string(),
+    &HashMap::new(),
+    PathBuf::new().as_path(),
+    &piranha_args,
+  );
+  let mut rule_store = RuleStore::new(&piranha_args);
+  let start_byte = source_code.find("false").unwrap();
+  let scope_query = source_code_unit
+    .get_enclosing_scope_query(
+      "Function-Method",
+      start_byte,
+      start_byte + "false".len(),
+      &mut rule_store,
+    )
+    .unwrap();
+
+  let query = scope_query.get_query();
+  assert!(query.contains("(#eq? @receiver_list \"(c *Client)\")"));
+  assert!(query.contains("(#eq? @fn \"run\")"));
+  assert!(query.contains("(#eq? @paramlist \"()\")"));
 }
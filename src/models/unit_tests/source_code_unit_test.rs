@@ -16,7 +16,7 @@ use tree_sitter::Parser;
 use crate::{
   constraint,
   models::{
-    default_configs::{JAVA, UNUSED_CODE_PATH},
+    default_configs::{GO, JAVA, UNUSED_CODE_PATH},
     language::PiranhaLanguage,
     piranha_arguments::PiranhaArgumentsBuilder,
     rule::InstantiatedRule,
@@ -253,3 +253,155 @@ fn test_satisfies_constraints_negative() {
     &mut rule_store,
   ));
 }
+
+/// Deletes the call to `function_name` in the Go `source_code`, and returns the updated code
+fn delete_go_call(source_code: &str, function_name: &str) -> String {
+  let _rule = piranha_rule! {
+    name= "delete_call",
+    query= "(
+      ((call_expression
+          function: (identifier) @function) @call)
+      (#eq? @function \"@function_name\")
+      )",
+    replace_node= "call",
+    replace= "",
+    holes= ["function_name"]
+  };
+  let rule = InstantiatedRule::new(
+    &_rule,
+    &HashMap::from([("function_name".to_string(), function_name.to_string())]),
+  );
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(UNUSED_CODE_PATH.to_string())
+    .language(PiranhaLanguage::from(GO))
+    .build();
+  let mut rule_store = RuleStore::new(&piranha_arguments);
+  let mut parser = piranha_arguments.language().parser();
+  let mut source_code_unit = SourceCodeUnit::new(
+    &mut parser,
+    source_code.to_string(),
+    &HashMap::new(),
+    PathBuf::new().as_path(),
+    &piranha_arguments,
+  );
+  let edit = source_code_unit
+    .get_edit(&rule, &mut rule_store, source_code_unit.root_node(), true)
+    .unwrap();
+  let _ = source_code_unit.apply_edit(&edit, &mut parser);
+  source_code_unit.code().to_string()
+}
+
+#[test]
+fn test_delete_collapses_double_blank_lines() {
+  let source_code = "package main\n\nfunc f() {\n\ta()\n\n\tb()\n\n\tc()\n}\n";
+  assert_eq!(
+    delete_go_call(source_code, "b"),
+    "package main\n\nfunc f() {\n\ta()\n\n\tc()\n}\n"
+  );
+}
+
+#[test]
+fn test_delete_first_statement_of_block() {
+  let source_code = "package main\n\nfunc f() {\n\ta()\n\n\tb()\n}\n";
+  assert_eq!(
+    delete_go_call(source_code, "a"),
+    "package main\n\nfunc f() {\n\tb()\n}\n"
+  );
+}
+
+#[test]
+fn test_delete_last_statement_of_block() {
+  let source_code = "package main\n\nfunc f() {\n\ta()\n\n\tb()\n}\n";
+  assert_eq!(
+    delete_go_call(source_code, "b"),
+    "package main\n\nfunc f() {\n\ta()\n}\n"
+  );
+}
+
+#[test]
+fn test_delete_retains_unrelated_blank_lines() {
+  let source_code = "package main\n\nfunc f() {\n\ta()\n\tb()\n\n\n\tc()\n}\n";
+  assert_eq!(
+    delete_go_call(source_code, "a"),
+    "package main\n\nfunc f() {\n\tb()\n\n\n\tc()\n}\n"
+  );
+}
+
+#[test]
+fn test_find_introduced_syntax_error() {
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let source_code = "package main\n\nfunc f() {\n\ta()\n}\n";
+  let mut source_code_unit = SourceCodeUnit::default(source_code, &mut parser, GO.to_string());
+  assert_eq!(
+    source_code_unit.find_introduced_syntax_error(&mut parser),
+    None
+  );
+
+  // E.g. a post-processing of the code left an unbalanced brace
+  source_code_unit.set_code("package main\n\nfunc f() {\n\ta()\n".to_string());
+  assert!(source_code_unit
+    .find_introduced_syntax_error(&mut parser)
+    .is_some());
+
+  source_code_unit.roll_back(&mut parser);
+  assert_eq!(source_code_unit.code(), source_code);
+  assert!(source_code_unit.rewrites().is_empty());
+  assert_eq!(
+    source_code_unit.find_introduced_syntax_error(&mut parser),
+    None
+  );
+}
+
+#[test]
+fn test_find_introduced_syntax_error_tolerates_original_errors() {
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let source_code = "package main\n\n// unused\nfunc f() {\n\ta(\n}\n";
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(UNUSED_CODE_PATH.to_string())
+    .language(PiranhaLanguage::from(GO))
+    .allow_dirty_ast(true)
+    .build();
+  let mut source_code_unit = SourceCodeUnit::new(
+    &mut parser,
+    source_code.to_string(),
+    &HashMap::new(),
+    PathBuf::new().as_path(),
+    &piranha_arguments,
+  );
+  // The edit before the original error shifts it
+  let _ = source_code_unit.apply_edit(
+    &Edit::delete_range(source_code, range(14, 24, 2, 0, 3, 0)),
+    &mut parser,
+  );
+  assert_eq!(
+    source_code_unit.code(),
+    "package main\n\nfunc f() {\n\ta(\n}\n"
+  );
+  assert_eq!(*source_code_unit.introduced_syntax_error(), None);
+  assert_eq!(
+    source_code_unit.find_introduced_syntax_error(&mut parser),
+    None
+  );
+}
+
+#[test]
+fn test_apply_edit_introducing_syntax_error() {
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let source_code = "package main\n\nfunc f() {\n\ta()\n}\n";
+  let mut source_code_unit = SourceCodeUnit::default(source_code, &mut parser, GO.to_string());
+
+  // Deleting the closing brace does not panic, the file is rolled back instead
+  let _ = source_code_unit.apply_edit(
+    &Edit::delete_range(source_code, range(30, 31, 4, 0, 4, 1)),
+    &mut parser,
+  );
+  assert!(source_code_unit.introduced_syntax_error().is_some());
+  assert_eq!(
+    source_code_unit.find_introduced_syntax_error(&mut parser),
+    *source_code_unit.introduced_syntax_error()
+  );
+
+  source_code_unit.roll_back(&mut parser);
+  assert_eq!(source_code_unit.code(), source_code);
+  assert_eq!(*source_code_unit.introduced_syntax_error(), None);
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{collections::HashMap, path::PathBuf};
+
+use tree_sitter::Parser;
+
+use crate::models::{
+  default_configs::GO, language::PiranhaLanguage, piranha_arguments::PiranhaArgumentsBuilder,
+  source_code_unit::SourceCodeUnit,
+};
+
+fn get_go_parser() -> Parser {
+  let mut parser = Parser::new();
+  parser
+    .set_language(*PiranhaLanguage::from(GO).language())
+    .unwrap();
+  parser
+}
+
+/// Returns the source code unit of the Go `code`, where `??` is an unsupported construct.
+fn get_source_code_unit(code: &str, parser: &mut Parser) -> SourceCodeUnit {
+  SourceCodeUnit::new(
+    parser,
+    code.to_string(),
+    &HashMap::new(),
+    PathBuf::new().as_path(),
+    &PiranhaArgumentsBuilder::default()
+      .path_to_codebase("some/test/path/".to_string())
+      .language(PiranhaLanguage::from(GO))
+      .unsupported_syntax(vec!["[?][?]".to_string()])
+      .build(),
+  )
+}
+
+// `??` stands for a syntax newer than the bundled grammar
+const CODE: &str = "package main
+
+func withDefault(m map[string]int, k string) int {
+\treturn m[k] ?? 0
+}
+
+func enabled() bool {
+\treturn true
+}
+";
+
+#[test]
+fn test_find_unsupported_functions() {
+  let mut parser = get_go_parser();
+  let source_code_unit = get_source_code_unit(CODE, &mut parser);
+
+  let skipped_functions = source_code_unit.skipped_functions();
+  assert_eq!(skipped_functions.len(), 1);
+  let skipped_function = &skipped_functions[0];
+  assert_eq!(skipped_function.name(), "withDefault");
+  assert_eq!(skipped_function.start_point().row, 2);
+  assert_eq!(skipped_function.error_point().row, 3);
+  assert!(skipped_function.reason().ends_with(&format!(
+    "at 4:{}",
+    skipped_function.error_point().column + 1
+  )));
+}
+
+#[test]
+fn test_skipped_function_ranges() {
+  let mut parser = get_go_parser();
+  let source_code_unit = get_source_code_unit(CODE, &mut parser);
+
+  let ranges = source_code_unit.skipped_function_ranges();
+  assert_eq!(ranges.len(), 1);
+  let start = CODE.find("func withDefault").unwrap();
+  assert_eq!(ranges[0].start_byte, start);
+  assert_eq!(ranges[0].end_byte, CODE.find("}\n").unwrap() + 1);
+}
+
+#[test]
+fn test_no_unsupported_functions() {
+  let mut parser = get_go_parser();
+  let source_code_unit = get_source_code_unit(
+    "package main\n\nfunc enabled() bool {\n\treturn true\n}\n",
+    &mut parser,
+  );
+  assert!(source_code_unit.skipped_functions().is_empty());
+  assert!(source_code_unit.skipped_function_ranges().is_empty());
+}
+
+/// The syntax errors outside of any function cannot be skipped, hence the whole file is rejected.
+#[test]
+#[should_panic(expected = "Produced syntactically incorrect source code")]
+fn test_syntax_error_outside_function() {
+  let mut parser = get_go_parser();
+  let code = "package main\n\nvar fallback = 1 ?? 0\n\nfunc enabled() bool {\n\treturn true\n}\n";
+  get_source_code_unit(code, &mut parser);
+}
+
+/// The syntax errors that are not one of the `unsupported_syntax` constructs (e.g. a typo) cannot be skipped either.
+#[test]
+#[should_panic(expected = "Produced syntactically incorrect source code")]
+fn test_syntax_error_not_unsupported_syntax() {
+  let mut parser = get_go_parser();
+  let code = "package main\n\nfunc greet() {\n\tfmt.Println(\"new\"\n}\n";
+  get_source_code_unit(code, &mut parser);
+}
+
+/// Without `unsupported_syntax`, no syntax error can be skipped.
+#[test]
+#[should_panic(expected = "Produced syntactically incorrect source code")]
+fn test_no_unsupported_syntax() {
+  let mut parser = get_go_parser();
+  SourceCodeUnit::default(CODE, &mut parser, GO.to_string());
+}
+
+/// The declarations the skipped functions refer to (e.g. the constant of a flag) are detected, so that they are not rewritten.
+#[test]
+fn test_skipped_function_references() {
+  let mut parser = get_go_parser();
+  let code = "package main
+
+const staleFlag = \"stale_flag\"
+
+func withDefault(m map[string]int, k string) int {
+\tif exp.BoolValue(staleFlag) {
+\t\treturn m[k] ?? 0
+\t}
+\treturn 0
+}
+";
+  let source_code_unit = get_source_code_unit(code, &mut parser);
+
+  let references = source_code_unit.skipped_function_references();
+  assert!(references.contains("staleFlag"));
+  let root_node = source_code_unit.root_node();
+  let const_declaration = root_node.named_child(1).unwrap();
+  assert_eq!(const_declaration.kind(), "const_declaration");
+  assert!(source_code_unit.declares_any(const_declaration, &references));
+}
@@ -0,0 +1,183 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::collections::HashSet;
+
+use getset::Getters;
+use itertools::Itertools;
+use regex::Regex;
+use tree_sitter::{Node, Point, Range};
+use tree_sitter_traversal::{traverse, Order};
+
+use super::source_code_unit::{get_error_nodes, SourceCodeUnit};
+
+/// The maximum length (in characters) of the unsupported construct quoted by a `SkippedFunction`.
+const MAX_CONSTRUCT_LENGTH: usize = 40;
+
+/// A function skipped by Piranha (i.e. neither matched nor rewritten), since it uses a syntax the grammar
+/// does not support (e.g. a syntax newer than the bundled grammar).
+#[derive(Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct SkippedFunction {
+  #[get = "pub"]
+  name: String,
+  // The start of the function in the original content of the file
+  #[get = "pub"]
+  start_point: Point,
+  // The start of the first syntax error within the function
+  #[get = "pub"]
+  error_point: Point,
+  // The code the grammar could not parse (its first line), or the node it expected (e.g. `)`)
+  #[get = "pub"]
+  construct: String,
+  #[get = "pub"]
+  is_missing: bool,
+}
+
+impl SkippedFunction {
+  /// Explains why the function was skipped, e.g. "unsupported syntax `?? 0` at 12:10".
+  pub(crate) fn reason(&self) -> String {
+    let position = format!(
+      "{}:{}",
+      self.error_point.row + 1,
+      self.error_point.column + 1
+    );
+    if self.is_missing {
+      format!("missing `{}` at {position}", self.construct)
+    } else {
+      format!("unsupported syntax `{}` at {position}", self.construct)
+    }
+  }
+}
+
+// Implements the graceful downgrade for the files using a syntax the grammar does not support
+impl SourceCodeUnit {
+  /// Returns the functions containing the syntax errors of the file (in the order of the file), or `None` if a syntax error
+  /// is outside of any function, or is not on a line matching one of the `unsupported_syntax` constructs (e.g. a typo),
+  /// i.e. the whole file cannot be analyzed.
+  pub(crate) fn find_unsupported_functions(&self) -> Option<Vec<SkippedFunction>> {
+    let unsupported_syntax = self
+      .piranha_arguments()
+      .unsupported_syntax()
+      .iter()
+      .filter_map(|pattern| Regex::new(pattern).ok())
+      .collect_vec();
+    let lines = self.code().lines().collect_vec();
+    let mut skipped_functions: Vec<SkippedFunction> = vec![];
+    for error in get_error_nodes(&self.root_node()) {
+      let function = self.enclosing_function(error)?;
+      let line = lines
+        .get(error.start_position().row)
+        .copied()
+        .unwrap_or_default();
+      if !unsupported_syntax.iter().any(|r| r.is_match(line)) {
+        return None;
+      }
+      if skipped_functions
+        .iter()
+        .any(|f| f.start_point == function.start_position())
+      {
+        continue;
+      }
+      let construct = if error.is_missing() {
+        error.kind().to_string()
+      } else {
+        let text = error.utf8_text(self.code().as_bytes()).unwrap_or_default();
+        let line = text.lines().map(str::trim).find(|l| !l.is_empty());
+        let line = line.unwrap_or_default();
+        if line.chars().count() > MAX_CONSTRUCT_LENGTH {
+          format!(
+            "{}...",
+            line.chars().take(MAX_CONSTRUCT_LENGTH).collect::<String>()
+          )
+        } else {
+          line.to_string()
+        }
+      };
+      skipped_functions.push(SkippedFunction {
+        name: function
+          .child_by_field_name("name")
+          .and_then(|n| n.utf8_text(self.code().as_bytes()).ok())
+          .unwrap_or_default()
+          .to_string(),
+        start_point: function.start_position(),
+        error_point: error.start_position(),
+        construct,
+        is_missing: error.is_missing(),
+      });
+    }
+    Some(skipped_functions)
+  }
+
+  /// Returns the ranges of the skipped functions in the current content of the file.
+  pub(crate) fn skipped_function_ranges(&self) -> Vec<Range> {
+    self
+      .skipped_function_nodes()
+      .iter()
+      .map(|f| f.range())
+      .collect_vec()
+  }
+
+  /// Returns the identifiers referenced from the skipped functions (e.g. the constant of a flag).
+  /// Since the code of these functions is not analyzed, the declarations of these identifiers are not rewritten (e.g. deleted as unused).
+  pub(crate) fn skipped_function_references(&self) -> HashSet<String> {
+    self
+      .skipped_function_nodes()
+      .into_iter()
+      .flat_map(|f| traverse(f.walk(), Order::Pre))
+      .filter(|n| n.kind().ends_with("identifier"))
+      .filter_map(|n| n.utf8_text(self.code().as_bytes()).ok())
+      .map(str::to_string)
+      .collect()
+  }
+
+  /// Checks if the `node` (or one of its descendants) declares one of the `identifiers`, i.e. is named by it
+  /// (e.g. `const staleFlag = "stale_flag"`).
+  pub(crate) fn declares_any(&self, node: Node, identifiers: &HashSet<String>) -> bool {
+    traverse(node.walk(), Order::Pre).any(|n| {
+      n.parent().and_then(|p| p.child_by_field_name("name")) == Some(n)
+        && n
+          .utf8_text(self.code().as_bytes())
+          .map_or(false, |name| identifiers.contains(name))
+    })
+  }
+
+  /// Returns the skipped functions in the current content of the file.
+  /// The functions are located by their syntax errors, which the edits never touch (i.e. they survive the re-parses).
+  fn skipped_function_nodes(&self) -> Vec<Node> {
+    if self.skipped_functions().is_empty() {
+      return vec![];
+    }
+    get_error_nodes(&self.root_node())
+      .into_iter()
+      .filter_map(|error| self.enclosing_function(error))
+      .dedup()
+      .collect_vec()
+  }
+
+  /// Returns the function (e.g. a Go function or method declaration) the `node` belongs to, if any.
+  fn enclosing_function<'a>(&self, node: Node<'a>) -> Option<Node<'a>> {
+    let function_kinds = self.piranha_arguments().language().function_kinds();
+    let mut current = Some(node);
+    while let Some(n) = current {
+      if function_kinds.contains(&n.kind()) {
+        return Some(n);
+      }
+      current = n.parent();
+    }
+    None
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/unsupported_syntax_test.rs"]
+mod unsupported_syntax_test;
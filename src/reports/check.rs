@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{
+  collections::{BTreeMap, BTreeSet},
+  path::Path,
+};
+
+use jwalk::WalkDir;
+
+use crate::{
+  models::language::PiranhaLanguage,
+  utilities::{eq_without_whitespace, read_file},
+};
+
+use super::patch::FilePatch;
+
+/// Compares the output of a run with the expected tree at `path_to_expected` (in the layout of the `expected` folder of the corpora).
+/// The output of each file (of the language) of the code base is its content after the cleanup : the content of `file_patches`
+/// for the edited files, else the content on disk. The files are compared ignoring whitespace, as in the test corpora.
+///
+/// Returns the mismatches, as patches from the expected content to the output (sorted by path).
+/// A file missing from the expected tree is reported as added, while a (deleted) file missing from the output is reported as deleted.
+pub(crate) fn get_mismatches(
+  file_patches: &[FilePatch], path_to_codebase: &str, path_to_expected: &str,
+  language: &PiranhaLanguage,
+) -> Vec<FilePatch> {
+  let mut output: BTreeMap<String, Option<String>> = read_tree(path_to_codebase, language)
+    .into_iter()
+    .map(|(path, content)| (path, Some(content)))
+    .collect();
+  for file_patch in file_patches {
+    output.insert(file_patch.path().to_string(), file_patch.updated().clone());
+  }
+  let expected = read_tree(path_to_expected, language);
+
+  output
+    .keys()
+    .chain(expected.keys())
+    .cloned()
+    .collect::<BTreeSet<_>>()
+    .into_iter()
+    .filter_map(|path| {
+      let actual = output.get(&path).cloned().flatten();
+      let expected_content = expected.get(&path);
+      let matches = match (&actual, expected_content) {
+        (Some(a), Some(e)) => eq_without_whitespace(a, e),
+        (None, None) => true,
+        _ => false,
+      };
+      (!matches)
+        .then(|| FilePatch::new(path, expected_content.cloned().unwrap_or_default(), actual))
+    })
+    .collect()
+}
+
+/// Reads the files of the language under `path_to_tree`, by their path relative to `path_to_tree`.
+fn read_tree(path_to_tree: &str, language: &PiranhaLanguage) -> BTreeMap<String, String> {
+  WalkDir::new(path_to_tree)
+    .into_iter()
+    .filter_map(|e| e.ok())
+    .filter(|de| language.can_parse(de))
+    .filter_map(|de| {
+      let path = de.path();
+      let relative_path = path
+        .strip_prefix(path_to_tree)
+        .ok()
+        .filter(|p| !p.as_os_str().is_empty())
+        .or_else(|| path.file_name().map(Path::new))?
+        .display()
+        .to_string();
+      read_file(&path)
+        .ok()
+        .map(|content| (relative_path, content))
+    })
+    .collect()
+}
+
+#[cfg(test)]
+#[path = "unit_tests/check_test.rs"]
+mod check_test;
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{collections::HashMap, fs};
+
+use getset::Getters;
+use serde_derive::{Deserialize, Serialize};
+
+use super::manifest::RunManifest;
+
+/// The work remaining when a run reached its `deadline`, so that a later run resumes from it
+/// instead of processing the whole code base again.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct Checkpoint {
+  // The code base the checkpoint was written for
+  #[get = "pub"]
+  path_to_codebase: String,
+  // The files that remain to be processed (relative to the code base)
+  #[get = "pub"]
+  remaining_files: Vec<String>,
+  // The global rules added by the processed files (e.g. the cleanup of an unused flag declaration)
+  #[get = "pub"]
+  global_rules: Vec<CheckpointedRule>,
+  // The package rules added by the processed files, by package (relative to the code base)
+  #[get = "pub"]
+  #[serde(default)]
+  package_rules: HashMap<String, Vec<CheckpointedRule>>,
+  // The substitutions for the global tags captured by the processed files
+  #[get = "pub"]
+  global_substitutions: HashMap<String, String>,
+  // What the run depends on, to reproduce it (see `RunManifest`)
+  #[get = "pub"]
+  #[serde(default, skip_serializing_if = "Option::is_none")]
+  manifest: Option<RunManifest>,
+}
+
+/// A global (or package) rule, identified by its name and the substitutions it was instantiated with.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct CheckpointedRule {
+  #[get = "pub"]
+  name: String,
+  #[get = "pub"]
+  substitutions: HashMap<String, String>,
+}
+
+impl CheckpointedRule {
+  pub(crate) fn new(name: String, substitutions: HashMap<String, String>) -> Self {
+    Self {
+      name,
+      substitutions,
+    }
+  }
+}
+
+impl Checkpoint {
+  pub(crate) fn new(
+    path_to_codebase: String, remaining_files: Vec<String>, global_rules: Vec<CheckpointedRule>,
+    package_rules: HashMap<String, Vec<CheckpointedRule>>,
+    global_substitutions: HashMap<String, String>,
+  ) -> Self {
+    Self {
+      path_to_codebase,
+      remaining_files,
+      global_rules,
+      package_rules,
+      global_substitutions,
+      manifest: None,
+    }
+  }
+
+  /// Embeds the manifest of the run the checkpoint was written by.
+  pub(crate) fn with_manifest(self, manifest: RunManifest) -> Self {
+    Self {
+      manifest: Some(manifest),
+      ..self
+    }
+  }
+
+  /// Reads the checkpoint from the Json file `path_to_checkpoint`.
+  pub(crate) fn read(path_to_checkpoint: &str) -> Self {
+    fs::read_to_string(path_to_checkpoint)
+      .ok()
+      .and_then(|content| serde_json::from_str(&content).ok())
+      .unwrap_or_else(|| {
+        panic!("Could not read the checkpoint from the file - {path_to_checkpoint}")
+      })
+  }
+
+  /// Writes the checkpoint to the Json file `path_to_checkpoint`.
+  pub(crate) fn write(&self, path_to_checkpoint: &str) {
+    if let Ok(contents) = serde_json::to_string_pretty(self) {
+      if fs::write(path_to_checkpoint, contents).is_ok() {
+        return;
+      }
+    }
+    panic!("Could not write the checkpoint to the file - {path_to_checkpoint}");
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/checkpoint_test.rs"]
+mod checkpoint_test;
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{
+  collections::{BTreeMap, HashMap},
+  fs,
+  path::{Path, PathBuf},
+};
+
+use log::info;
+use serde_derive::Serialize;
+
+use super::patch::FilePatch;
+
+/// The configuration files copied to the `configurations` folder of a corpus case.
+pub(crate) const CONFIGURATION_FILES: [&str; 2] = ["rules.toml", "edges.toml"];
+
+/// The file (in the `configurations` folder of a corpus case) recording the substitutions of the run.
+pub(crate) const SUBSTITUTIONS_FILE: &str = "substitutions.toml";
+
+#[derive(Serialize, Debug)]
+struct CorpusSubstitutions {
+  substitutions: BTreeMap<String, String>,
+}
+
+/// Returns the path of the first unused case of the corpus, i.e. `<path_to_corpus>/case_<n>`.
+pub(crate) fn next_case_path(path_to_corpus: &Path) -> PathBuf {
+  (1..)
+    .map(|i| path_to_corpus.join(format!("case_{i}")))
+    .find(|p| !p.exists())
+    .unwrap()
+}
+
+/// Records the edited files as a new case of the regression corpus at `path_to_corpus`, in the layout of `test-resources`, i.e.
+/// * `input` : the content of each edited file before the cleanup
+/// * `expected` : its content after the cleanup (deleted files are omitted)
+/// * `configurations` : the `rules.toml` and `edges.toml` found in `path_to_configurations` (if any), and the substitutions of the run
+///
+/// The unchanged files are not recorded. Returns the path of the new case, or `None` if no file was edited.
+pub(crate) fn write_corpus_case(
+  file_patches: &[FilePatch], path_to_configurations: &str,
+  substitutions: &HashMap<String, String>, path_to_corpus: &String,
+) -> Option<PathBuf> {
+  let edited = file_patches
+    .iter()
+    .filter(|p| p.updated().as_ref() != Some(p.original()))
+    .collect::<Vec<_>>();
+  if edited.is_empty() {
+    return None;
+  }
+
+  let case = next_case_path(Path::new(path_to_corpus));
+  for file_patch in edited {
+    let relative_path = case_file_path(file_patch.path());
+    write_file(
+      &case.join("input").join(&relative_path),
+      file_patch.original(),
+    );
+    if let Some(updated) = file_patch.updated() {
+      write_file(&case.join("expected").join(&relative_path), updated);
+    }
+  }
+
+  let configurations = case.join("configurations");
+  for file_name in CONFIGURATION_FILES {
+    let source = Path::new(path_to_configurations).join(file_name);
+    if !path_to_configurations.is_empty() && source.is_file() {
+      if let Ok(content) = fs::read_to_string(&source) {
+        write_file(&configurations.join(file_name), &content);
+      }
+    }
+  }
+  let substitutions = CorpusSubstitutions {
+    substitutions: substitutions.clone().into_iter().collect(),
+  };
+  write_file(
+    &configurations.join(SUBSTITUTIONS_FILE),
+    &toml::to_string(&substitutions).unwrap(),
+  );
+
+  info!(
+    "Recorded the edited files as the corpus case {}",
+    case.display()
+  );
+  Some(case)
+}
+
+/// Returns the path of the file within the `input` (or `expected`) folder of a case.
+/// The files outside the code base (i.e. with an absolute path) are recorded by their name.
+fn case_file_path(path: &str) -> PathBuf {
+  let path = Path::new(path);
+  if path.is_absolute() {
+    return PathBuf::from(path.file_name().unwrap_or_default());
+  }
+  path.to_path_buf()
+}
+
+fn write_file(path: &Path, content: &str) {
+  let written = path
+    .parent()
+    .map_or(Ok(()), fs::create_dir_all)
+    .and_then(|_| fs::write(path, content));
+  if written.is_err() {
+    panic!(
+      "Could not write the corpus case to the file - {}",
+      path.display()
+    );
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/corpus_test.rs"]
+mod corpus_test;
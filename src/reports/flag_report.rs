@@ -0,0 +1,147 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use getset::Getters;
+use itertools::Itertools;
+use serde_derive::Serialize;
+
+use super::{heatmap::get_package, manifest::RunManifest};
+
+/// The flag report, i.e. the cleanups by flag along with the manifest of the run.
+#[derive(Serialize, Debug)]
+struct FlagReport<'a> {
+  manifest: &'a RunManifest,
+  flags: &'a [FlagCleanup],
+}
+
+/// The edits of the cleanup of a flag, grouped by package and file, so that the cleanup of each flag
+/// of a multi-flag run can be reviewed (and reverted) independently.
+#[derive(Serialize, Debug, Clone, PartialEq, Eq, Getters)]
+pub(crate) struct FlagCleanup {
+  // The flag captured by the seed matches of the edits, `None` for the edits that cannot be attributed to a flag
+  #[get = "pub"]
+  flag: Option<String>,
+  // Number of files edited for this flag
+  #[get = "pub"]
+  files: usize,
+  #[get = "pub"]
+  edits: usize,
+  #[get = "pub"]
+  packages: Vec<PackageCleanup>,
+}
+
+/// The edits of the cleanup of a flag in a Go package, i.e. in the files of a directory.
+#[derive(Serialize, Debug, Clone, PartialEq, Eq, Getters)]
+pub(crate) struct PackageCleanup {
+  #[get = "pub"]
+  package: String,
+  #[get = "pub"]
+  files: Vec<FileCleanup>,
+}
+
+/// The edits of the cleanup of a flag in a file.
+#[derive(Serialize, Debug, Clone, PartialEq, Eq, Getters)]
+pub(crate) struct FileCleanup {
+  // The path of the file (relative to the code base)
+  #[get = "pub"]
+  path: String,
+  #[get = "pub"]
+  edits: Vec<FlagEdit>,
+}
+
+/// An edit of the cleanup of a flag (in the order of application).
+#[derive(Serialize, Debug, Clone, PartialEq, Eq, Getters)]
+pub(crate) struct FlagEdit {
+  #[get = "pub"]
+  rule: String,
+  // The (1-based) line of the edit, in the content of the file when the edit was applied
+  #[get = "pub"]
+  line: usize,
+  #[get = "pub"]
+  matched: String,
+  #[get = "pub"]
+  replacement: String,
+}
+
+impl FlagEdit {
+  pub(crate) fn new(rule: String, line: usize, matched: String, replacement: String) -> Self {
+    Self {
+      rule,
+      line,
+      matched,
+      replacement,
+    }
+  }
+}
+
+/// Groups the edits of each file (`(flag, path, edit)`) by flag, then by package and file.
+/// The flags are sorted by name (the edits without a flag come last), the packages and files by path.
+pub(crate) fn get_flag_cleanups(
+  edits: Vec<(Option<String>, String, FlagEdit)>,
+) -> Vec<FlagCleanup> {
+  edits
+    .into_iter()
+    .into_group_map_by(|(flag, _, _)| flag.clone())
+    .into_iter()
+    .sorted_by(|(a, _), (b, _)| (a.is_none(), a).cmp(&(b.is_none(), b)))
+    .map(|(flag, edits)| {
+      let number_of_edits = edits.len();
+      let files_by_path = edits
+        .into_iter()
+        .map(|(_, path, edit)| (path, edit))
+        .into_group_map();
+      let packages = files_by_path
+        .into_iter()
+        .map(|(path, edits)| FileCleanup { path, edits })
+        .into_group_map_by(|file| get_package(&file.path))
+        .into_iter()
+        .sorted_by(|(a, _), (b, _)| a.cmp(b))
+        .map(|(package, files)| PackageCleanup {
+          package,
+          files: files
+            .into_iter()
+            .sorted_by(|a, b| a.path.cmp(&b.path))
+            .collect_vec(),
+        })
+        .collect_vec();
+      FlagCleanup {
+        flag,
+        files: packages.iter().map(|p| p.files.len()).sum(),
+        edits: number_of_edits,
+        packages,
+      }
+    })
+    .collect_vec()
+}
+
+/// Writes the cleanups by flag (under `flags`) to the Json file `path_to_flag_report`, along with the manifest of the run.
+pub(crate) fn write_flag_report(
+  flag_cleanups: &[FlagCleanup], manifest: &RunManifest, path_to_flag_report: &String,
+) {
+  let flag_report = FlagReport {
+    manifest,
+    flags: flag_cleanups,
+  };
+  if let Ok(contents) = serde_json::to_string_pretty(&flag_report) {
+    if fs::write(path_to_flag_report, contents).is_ok() {
+      return;
+    }
+  }
+  panic!("Could not write the flag report to the file - {path_to_flag_report}");
+}
+
+#[cfg(test)]
+#[path = "unit_tests/flag_report_test.rs"]
+mod flag_report_test;
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{fs, path::Path};
+
+use getset::Getters;
+use itertools::Itertools;
+use serde_derive::Serialize;
+
+use super::manifest::RunManifest;
+
+/// The package heatmap, i.e. the ranked packages along with the manifest of the run.
+#[derive(Serialize, Debug)]
+struct PackageHeatmap<'a> {
+  manifest: &'a RunManifest,
+  packages: &'a [PackageUsage],
+}
+
+/// The (stale flag) usages found in a Go package, i.e. in the files of a directory.
+#[derive(Serialize, Debug, Clone, PartialEq, Eq, Getters)]
+pub(crate) struct PackageUsage {
+  // The directory of the package (relative to the code base)
+  #[get = "pub"]
+  package: String,
+  // Number of files of the package touched or matched by Piranha
+  #[get = "pub"]
+  files: usize,
+  #[get = "pub"]
+  matches: usize,
+  #[get = "pub"]
+  rewrites: usize,
+  // The effort to review the cleanup of this package, i.e. the number of matches and rewrites
+  #[get = "pub"]
+  effort: usize,
+}
+
+/// Aggregates the number of matches and rewrites of each file (`(path, matches, rewrites)`) by package,
+/// and ranks the packages by decreasing effort (ties are broken by the number of files, then the name).
+pub(crate) fn get_package_heatmap(file_usages: &[(String, usize, usize)]) -> Vec<PackageUsage> {
+  file_usages
+    .iter()
+    .into_group_map_by(|(path, _, _)| get_package(path))
+    .into_iter()
+    .map(|(package, usages)| {
+      let matches: usize = usages.iter().map(|(_, m, _)| m).sum();
+      let rewrites: usize = usages.iter().map(|(_, _, r)| r).sum();
+      PackageUsage {
+        package,
+        files: usages.len(),
+        matches,
+        rewrites,
+        effort: matches + rewrites,
+      }
+    })
+    .sorted_by(|a, b| {
+      (b.effort, b.files)
+        .cmp(&(a.effort, a.files))
+        .then_with(|| a.package.cmp(&b.package))
+    })
+    .collect_vec()
+}
+
+/// Returns the package (i.e. the directory) of the file `path`.
+pub(crate) fn get_package(path: &str) -> String {
+  Path::new(path)
+    .parent()
+    .map(|p| p.display().to_string())
+    .filter(|p| !p.is_empty())
+    .unwrap_or_else(|| ".".to_string())
+}
+
+/// Writes the package heatmap (under `packages`) to the Json file `path_to_package_heatmap`, along with the manifest of the run.
+pub(crate) fn write_package_heatmap(
+  heatmap: &[PackageUsage], manifest: &RunManifest, path_to_package_heatmap: &String,
+) {
+  let package_heatmap = PackageHeatmap {
+    manifest,
+    packages: heatmap,
+  };
+  if let Ok(contents) = serde_json::to_string_pretty(&package_heatmap) {
+    if fs::write(path_to_package_heatmap, contents).is_ok() {
+      return;
+    }
+  }
+  panic!("Could not write the package heatmap to the file - {path_to_package_heatmap}");
+}
+
+#[cfg(test)]
+#[path = "unit_tests/heatmap_test.rs"]
+mod heatmap_test;
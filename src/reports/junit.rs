@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use getset::Getters;
+use itertools::Itertools;
+
+use super::manifest::RunManifest;
+
+/// The outcome of a (JUnit) test case, i.e. of a file analyzed by Piranha.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(crate) enum JUnitStatus {
+  /// The file was cleaned up
+  Passed,
+  /// The file did not contain any usages
+  Skipped(String),
+  /// The file could not be cleaned up (errors or uncertain sites)
+  Failed(String),
+}
+
+#[derive(Debug, Clone, Getters)]
+pub(crate) struct JUnitTestCase {
+  #[get = "pub"]
+  name: String,
+  #[get = "pub"]
+  status: JUnitStatus,
+}
+
+impl JUnitTestCase {
+  pub(crate) fn new(name: String, status: JUnitStatus) -> Self {
+    Self { name, status }
+  }
+
+  fn to_xml(&self) -> String {
+    let name = escape_xml(&self.name);
+    match &self.status {
+      JUnitStatus::Passed => format!("    <testcase classname=\"piranha\" name=\"{name}\"/>"),
+      JUnitStatus::Skipped(message) => format!(
+        "    <testcase classname=\"piranha\" name=\"{name}\">\n      <skipped message=\"{}\"/>\n    </testcase>",
+        escape_xml(message)
+      ),
+      JUnitStatus::Failed(message) => format!(
+        "    <testcase classname=\"piranha\" name=\"{name}\">\n      <failure message=\"{}\"/>\n    </testcase>",
+        escape_xml(message)
+      ),
+    }
+  }
+}
+
+/// Renders the test cases as a JUnit XML report (with a single test suite named `piranha`).
+/// The manifest of the run (if any) is rendered as the properties of the test suite.
+pub(crate) fn to_junit_xml(test_cases: &[JUnitTestCase], manifest: Option<&RunManifest>) -> String {
+  let count = |f: fn(&JUnitStatus) -> bool| test_cases.iter().filter(|t| f(t.status())).count();
+  let failures = count(|s| matches!(s, JUnitStatus::Failed(_)));
+  let skipped = count(|s| matches!(s, JUnitStatus::Skipped(_)));
+  let header = format!(
+    "<testsuite name=\"piranha\" tests=\"{}\" failures=\"{failures}\" skipped=\"{skipped}\">",
+    test_cases.len()
+  );
+  [
+    "<?xml version=\"1.0\" encoding=\"UTF-8\"?>".to_string(),
+    "<testsuites>".to_string(),
+    format!("  {header}"),
+  ]
+  .into_iter()
+  .chain(manifest.map(to_junit_properties))
+  .chain(test_cases.iter().map(JUnitTestCase::to_xml))
+  .chain(["  </testsuite>".to_string(), "</testsuites>".to_string()])
+  .join("\n")
+}
+
+/// Renders the manifest as the `properties` of the test suite, named after its fields (e.g. `piranha.rule_pack.rules.toml`).
+fn to_junit_properties(manifest: &RunManifest) -> String {
+  let optional = |value: &Option<String>| value.clone().unwrap_or_default();
+  let properties = [
+    ("piranha.version", manifest.piranha_version().to_string()),
+    ("piranha.language", manifest.language().to_string()),
+    ("piranha.config_hash", optional(manifest.config_hash())),
+    (
+      "piranha.arguments",
+      serde_json::to_string(manifest.arguments()).unwrap(),
+    ),
+    (
+      "piranha.arguments_hash",
+      manifest.arguments_hash().to_string(),
+    ),
+    ("piranha.input_commit", optional(manifest.input_commit())),
+  ]
+  .into_iter()
+  .map(|(name, value)| (name.to_string(), value))
+  .chain(manifest.rule_packs().iter().map(|pack| {
+    (
+      format!("piranha.rule_pack.{}", pack.name()),
+      pack.hash().to_string(),
+    )
+  }))
+  .map(|(name, value)| {
+    format!(
+      "      <property name=\"{}\" value=\"{}\"/>",
+      escape_xml(&name),
+      escape_xml(&value)
+    )
+  })
+  .join("\n");
+  format!("    <properties>\n{properties}\n    </properties>")
+}
+
+/// Writes the JUnit XML report (along with the manifest of the run) to the file `path_to_junit_report`.
+pub(crate) fn write_junit_report(
+  test_cases: &[JUnitTestCase], manifest: &RunManifest, path_to_junit_report: &String,
+) {
+  if fs::write(
+    path_to_junit_report,
+    to_junit_xml(test_cases, Some(manifest)),
+  )
+  .is_err()
+  {
+    panic!("Could not write the JUnit report to the file - {path_to_junit_report}");
+  }
+}
+
+/// Escapes the characters that are not allowed in XML attribute values.
+fn escape_xml(value: &str) -> String {
+  value
+    .replace('&', "&amp;")
+    .replace('<', "&lt;")
+    .replace('>', "&gt;")
+    .replace('"', "&quot;")
+    .replace('\'', "&apos;")
+}
+
+#[cfg(test)]
+#[path = "unit_tests/junit_test.rs"]
+mod junit_test;
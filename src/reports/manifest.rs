@@ -0,0 +1,270 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{
+  collections::BTreeMap,
+  fs,
+  path::{Path, PathBuf},
+};
+
+use getset::Getters;
+use glob::Pattern;
+use serde_derive::{Deserialize, Serialize};
+use sha2::{Digest, Sha256};
+
+use crate::models::piranha_arguments::PiranhaArguments;
+
+use super::corpus::CONFIGURATION_FILES;
+
+/// The version of the Piranha binary (the built-in rule packs are versioned along with it).
+pub(crate) const PIRANHA_VERSION: &str = env!("CARGO_PKG_VERSION");
+
+/// Records what a Piranha run depends on, so that its cleanup can be reproduced (or audited) later byte-for-byte.
+/// It is embedded in each report of the run (except the output summary and the patches).
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct RunManifest {
+  #[get = "pub"]
+  piranha_version: String,
+  #[get = "pub"]
+  language: String,
+  // The built-in rule packs of the language (sorted by name), they are versioned along with Piranha (i.e. `piranha_version`)
+  #[get = "pub"]
+  rule_packs: Vec<RulePack>,
+  // The hash of the `rules.toml` and `edges.toml` found in `path_to_configurations`, `None` if there are none
+  #[get = "pub"]
+  config_hash: Option<String>,
+  // The arguments of the run affecting the cleanup
+  #[get = "pub"]
+  arguments: RunArguments,
+  // The hash of the `arguments`
+  #[get = "pub"]
+  arguments_hash: String,
+  // The commit checked out in the code base, `None` if it is not within a git repository.
+  // Note that the uncommitted changes are not recorded.
+  #[get = "pub"]
+  input_commit: Option<String>,
+}
+
+/// A built-in rule file, identified by its name and the hash of its content.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct RulePack {
+  #[get = "pub"]
+  name: String,
+  #[get = "pub"]
+  hash: String,
+}
+
+/// The effective arguments of a run (i.e. including the defaults) that affect its cleanup, rendered (as Json) with their keys sorted before being hashed.
+/// The paths of the reports, as well as the dry-run and the `deadline` arguments, do not affect the edits, hence they are left out.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct RunArguments {
+  #[get = "pub"]
+  substitutions: BTreeMap<String, String>,
+  #[get = "pub"]
+  include: Vec<String>,
+  #[get = "pub"]
+  exclude: Vec<String>,
+  #[get = "pub"]
+  renames: Vec<(String, String)>,
+  // The content of the `path_to_config_values` file (if any)
+  #[get = "pub"]
+  config_values: Option<String>,
+  #[get = "pub"]
+  disabled_builtin_rules: Vec<String>,
+  #[get = "pub"]
+  comment_out_deletions: Vec<String>,
+  #[get = "pub"]
+  error_result_handling: String,
+  #[get = "pub"]
+  ok_result_handling: Option<String>,
+  #[get = "pub"]
+  keep_flag_calls: bool,
+  #[get = "pub"]
+  flag_call_replacement: Option<String>,
+  #[get = "pub"]
+  deleted_branch_replacement: Option<String>,
+  #[get = "pub"]
+  removed_flags: Vec<String>,
+  #[get = "pub"]
+  lint_uncleanable_patterns: bool,
+  #[get = "pub"]
+  scan_flag_remnants: bool,
+  #[get = "pub"]
+  strict: bool,
+  #[get = "pub"]
+  provenance_comment: Option<String>,
+  #[get = "pub"]
+  strip_provenance_comments: bool,
+  #[get = "pub"]
+  file_size_threshold: u64,
+  #[get = "pub"]
+  force_large_files: bool,
+  #[get = "pub"]
+  max_nesting_depth: u32,
+  #[get = "pub"]
+  allow_dirty_ast: bool,
+  #[get = "pub"]
+  unsupported_syntax: Vec<String>,
+  #[get = "pub"]
+  delete_file_if_empty: bool,
+  #[get = "pub"]
+  delete_consecutive_new_lines: bool,
+  #[get = "pub"]
+  cleanup_comments: bool,
+  #[get = "pub"]
+  cleanup_comments_buffer: i32,
+  #[get = "pub"]
+  number_of_ancestors_in_parent_scope: u8,
+  #[get = "pub"]
+  global_tag_prefix: String,
+}
+
+impl RunArguments {
+  fn new(piranha_arguments: &PiranhaArguments) -> Self {
+    let patterns = |patterns: &Vec<Pattern>| {
+      patterns
+        .iter()
+        .map(|p| p.as_str().to_string())
+        .collect::<Vec<_>>()
+    };
+    Self {
+      substitutions: piranha_arguments
+        .input_substitutions()
+        .into_iter()
+        .collect(),
+      include: patterns(piranha_arguments.include()),
+      exclude: patterns(piranha_arguments.exclude()),
+      renames: piranha_arguments.get_renames(),
+      config_values: piranha_arguments
+        .path_to_config_values()
+        .as_ref()
+        .and_then(|path| fs::read_to_string(path).ok()),
+      disabled_builtin_rules: piranha_arguments.disabled_builtin_rules().clone(),
+      comment_out_deletions: piranha_arguments.comment_out_deletions().clone(),
+      error_result_handling: piranha_arguments.error_result_handling().to_string(),
+      ok_result_handling: piranha_arguments.ok_result_handling().clone(),
+      keep_flag_calls: *piranha_arguments.keep_flag_calls(),
+      flag_call_replacement: piranha_arguments.flag_call_replacement().clone(),
+      deleted_branch_replacement: piranha_arguments.deleted_branch_replacement().clone(),
+      removed_flags: piranha_arguments.removed_flags().clone(),
+      lint_uncleanable_patterns: *piranha_arguments.lint_uncleanable_patterns(),
+      scan_flag_remnants: *piranha_arguments.scan_flag_remnants(),
+      strict: *piranha_arguments.strict(),
+      provenance_comment: piranha_arguments.provenance_comment().clone(),
+      strip_provenance_comments: *piranha_arguments.strip_provenance_comments(),
+      file_size_threshold: *piranha_arguments.file_size_threshold(),
+      force_large_files: *piranha_arguments.force_large_files(),
+      max_nesting_depth: *piranha_arguments.max_nesting_depth(),
+      allow_dirty_ast: *piranha_arguments.allow_dirty_ast(),
+      unsupported_syntax: piranha_arguments.unsupported_syntax().clone(),
+      delete_file_if_empty: *piranha_arguments.delete_file_if_empty(),
+      delete_consecutive_new_lines: *piranha_arguments.delete_consecutive_new_lines(),
+      cleanup_comments: *piranha_arguments.cleanup_comments(),
+      cleanup_comments_buffer: *piranha_arguments.cleanup_comments_buffer(),
+      number_of_ancestors_in_parent_scope: *piranha_arguments.number_of_ancestors_in_parent_scope(),
+      global_tag_prefix: piranha_arguments.global_tag_prefix().to_string(),
+    }
+  }
+}
+
+impl RunManifest {
+  pub(crate) fn new(piranha_arguments: &PiranhaArguments) -> Self {
+    let mut rule_packs = piranha_arguments
+      .language()
+      .rule_packs()
+      .into_iter()
+      .map(|(name, content)| RulePack {
+        name: name.to_string(),
+        hash: get_sha256(content),
+      })
+      .collect::<Vec<_>>();
+    rule_packs.sort_by(|a, b| a.name.cmp(&b.name));
+    let arguments = RunArguments::new(piranha_arguments);
+    Self {
+      piranha_version: PIRANHA_VERSION.to_string(),
+      language: piranha_arguments.get_language(),
+      rule_packs,
+      config_hash: get_config_hash(piranha_arguments.path_to_configurations()),
+      arguments_hash: get_sha256(&serde_json::to_string(&arguments).unwrap()),
+      arguments,
+      input_commit: get_head_commit(piranha_arguments.path_to_codebase()),
+    }
+  }
+}
+
+/// Returns the hash of the configuration files found in `path_to_configurations` (along with their name), `None` if there are none.
+fn get_config_hash(path_to_configurations: &str) -> Option<String> {
+  if path_to_configurations.is_empty() {
+    return None;
+  }
+  let content = CONFIGURATION_FILES
+    .iter()
+    .filter_map(|file_name| {
+      fs::read_to_string(Path::new(path_to_configurations).join(file_name))
+        .ok()
+        .map(|content| format!("{file_name}\n{content}\n"))
+    })
+    .collect::<String>();
+  (!content.is_empty()).then(|| get_sha256(&content))
+}
+
+/// Returns the SHA-256 digest of the content (in lowercase hexadecimal).
+pub(crate) fn get_sha256(content: &str) -> String {
+  format!("{:x}", Sha256::digest(content.as_bytes()))
+}
+
+/// Returns the commit checked out in the git repository enclosing `path_to_codebase` (if any),
+/// by resolving its `HEAD` against the loose and the packed refs (as `git rev-parse HEAD` would).
+pub(crate) fn get_head_commit(path_to_codebase: &str) -> Option<String> {
+  let path_to_codebase = fs::canonicalize(path_to_codebase).ok()?;
+  let git_dir = path_to_codebase.ancestors().find_map(get_git_dir)?;
+  let head = fs::read_to_string(git_dir.join("HEAD")).ok()?;
+  let head = head.trim();
+  let reference = match head.strip_prefix("ref:") {
+    Some(reference) => reference.trim(),
+    // Detached `HEAD`
+    None => return Some(head.to_string()),
+  };
+  // The refs of a worktree are shared with the main repository
+  let common_dir = fs::read_to_string(git_dir.join("commondir"))
+    .map(|p| git_dir.join(p.trim()))
+    .unwrap_or_else(|_| git_dir.clone());
+  [&git_dir, &common_dir].iter().find_map(|dir| {
+    fs::read_to_string(dir.join(reference))
+      .ok()
+      .map(|commit| commit.trim().to_string())
+      .or_else(|| {
+        fs::read_to_string(dir.join("packed-refs"))
+          .ok()?
+          .lines()
+          .filter_map(|line| line.split_once(' '))
+          .find(|(_, r)| *r == reference)
+          .map(|(commit, _)| commit.to_string())
+      })
+  })
+}
+
+/// Returns the git directory of `dir`, i.e. its `.git` folder (or the folder a `.git` file points to, e.g. for a worktree).
+fn get_git_dir(dir: &Path) -> Option<PathBuf> {
+  let dot_git = dir.join(".git");
+  if dot_git.is_dir() {
+    return Some(dot_git);
+  }
+  let content = fs::read_to_string(&dot_git).ok()?;
+  let git_dir = content.trim().strip_prefix("gitdir:")?.trim();
+  Some(dir.join(git_dir))
+}
+
+#[cfg(test)]
+#[path = "unit_tests/manifest_test.rs"]
+mod manifest_test;
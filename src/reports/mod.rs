@@ -0,0 +1,28 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+//! Defines the reports (other than the output summary) emitted at the end of a Piranha run.
+
+pub(crate) mod check;
+pub(crate) mod checkpoint;
+pub(crate) mod corpus;
+pub(crate) mod flag_report;
+pub(crate) mod heatmap;
+pub(crate) mod junit;
+pub(crate) mod manifest;
+pub(crate) mod ownership;
+pub(crate) mod patch;
+pub(crate) mod plan;
+pub(crate) mod quick_fix;
+pub(crate) mod run_report;
+pub(crate) mod sarif;
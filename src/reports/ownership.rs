@@ -0,0 +1,171 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{collections::BTreeMap, fs, path::Path};
+
+use glob::{MatchOptions, Pattern};
+use itertools::Itertools;
+
+use super::patch::{write_patch, FilePatch};
+
+/// The owner of the files not covered by the CODEOWNERS file (or by the path prefixes).
+pub(crate) const UNOWNED: &str = "unowned";
+
+/// As in CODEOWNERS files, `*` does not match `/` (unlike `**`).
+const MATCH_OPTIONS: MatchOptions = MatchOptions {
+  case_sensitive: true,
+  require_literal_separator: true,
+  require_literal_leading_dot: false,
+};
+
+/// The rules of a CODEOWNERS file, i.e. a path pattern followed by its owners (e.g. `/services/payments/ @org/payments`).
+/// As in git, the last matching rule determines the owners of a file, and a rule without owners leaves the file unowned.
+#[derive(Debug, Clone, Default)]
+pub(crate) struct CodeOwners {
+  rules: Vec<(Vec<Pattern>, Vec<String>)>,
+}
+
+impl CodeOwners {
+  pub(crate) fn new(content: &str) -> Self {
+    let rules = content
+      .lines()
+      .map(str::trim)
+      .filter(|line| !line.is_empty() && !line.starts_with('#'))
+      .filter_map(|line| {
+        let mut parts = line.split_whitespace();
+        let patterns = to_glob_patterns(parts.next()?);
+        Some((patterns, parts.map(|owner| owner.to_string()).collect_vec()))
+      })
+      .collect_vec();
+    Self { rules }
+  }
+
+  /// Reads the CODEOWNERS file at `path_to_codeowners`.
+  pub(crate) fn read(path_to_codeowners: &String) -> Self {
+    match fs::read_to_string(path_to_codeowners) {
+      Ok(content) => Self::new(&content),
+      Err(_) => panic!("Could not read the CODEOWNERS file - {path_to_codeowners}"),
+    }
+  }
+
+  /// Returns the owners of the file at `path` (relative to the code base), if any.
+  pub(crate) fn owners_of(&self, path: &str) -> Option<&Vec<String>> {
+    self
+      .rules
+      .iter()
+      .rev()
+      .find(|(patterns, _)| patterns.iter().any(|p| p.matches_with(path, MATCH_OPTIONS)))
+      .map(|(_, owners)| owners)
+      .filter(|owners| !owners.is_empty())
+  }
+}
+
+/// Translates the path pattern of a CODEOWNERS rule to glob patterns (matching the path of a file relative to the code base).
+/// * A pattern starting with (or containing) a `/` is relative to the root, otherwise it matches at any depth (e.g. `*.go`).
+/// * A pattern matches the files it names, as well as the files under the directories it names (e.g. `docs/`),
+///   except a pattern ending with `/*` which only matches the files directly in the directory (e.g. `docs/*`).
+fn to_glob_patterns(pattern: &str) -> Vec<Pattern> {
+  let anchored = pattern.trim_end_matches('/').contains('/');
+  let pattern = pattern.trim_start_matches('/').trim_end_matches('/');
+  let pattern = if anchored || pattern.starts_with("**") {
+    pattern.to_string()
+  } else {
+    format!("**/{pattern}")
+  };
+  let mut patterns = vec![pattern.clone()];
+  if !pattern.ends_with("/*") {
+    patterns.push(format!("{pattern}/**"));
+  }
+  patterns
+    .iter()
+    .filter_map(|p| Pattern::new(p).ok())
+    .collect_vec()
+}
+
+/// Returns the longest of the `prefixes` the file at `path` (relative to the code base) is under, if any.
+/// A prefix is matched on whole path components (i.e. `services/pay` does not cover `services/payments/a.go`).
+pub(crate) fn longest_path_prefix<'a>(prefixes: &'a [String], path: &str) -> Option<&'a String> {
+  prefixes
+    .iter()
+    .filter(|prefix| Path::new(path).starts_with(prefix.trim_end_matches('/')))
+    .max_by_key(|prefix| prefix.trim_end_matches('/').len())
+}
+
+/// Splits the file patches by owner, as given by `owner_of` (the files without owner are attributed to `unowned`).
+/// The owners are named after `owner_name`, e.g. `@org/payments` is named `org_payments`.
+pub(crate) fn split_by_owner<F>(
+  file_patches: &[FilePatch], owner_of: F,
+) -> BTreeMap<String, Vec<FilePatch>>
+where
+  F: Fn(&str) -> Option<String>,
+{
+  let mut file_patches_by_owner: BTreeMap<String, Vec<FilePatch>> = BTreeMap::new();
+  for file_patch in file_patches {
+    let owner = owner_of(file_patch.path())
+      .map(|o| owner_name(&o))
+      .unwrap_or_else(|| UNOWNED.to_string());
+    file_patches_by_owner
+      .entry(owner)
+      .or_default()
+      .push(file_patch.clone());
+  }
+  file_patches_by_owner
+}
+
+/// Names an owner (or a path prefix) so that it can be part of a file name.
+/// The leading `@` of the owners are dropped, the other special characters are replaced with `_`, and several owners are joined with `+`.
+pub(crate) fn owner_name(owner: &str) -> String {
+  owner
+    .split_whitespace()
+    .map(|o| {
+      o.trim_start_matches('@')
+        .trim_matches('/')
+        .chars()
+        .map(|c| {
+          if c.is_ascii_alphanumeric() || c == '-' || c == '_' || c == '.' {
+            c
+          } else {
+            '_'
+          }
+        })
+        .collect::<String>()
+    })
+    .join("+")
+}
+
+/// Returns the path of the patch of the `owner`, next to `path_to_patch` (e.g. `edits.org_payments.patch` for `edits.patch`).
+pub(crate) fn path_to_owner_patch(path_to_patch: &str, owner: &str) -> String {
+  let path = Path::new(path_to_patch);
+  let stem = path
+    .file_stem()
+    .and_then(|s| s.to_str())
+    .unwrap_or_default();
+  let file_name = match path.extension().and_then(|e| e.to_str()) {
+    Some(extension) => format!("{stem}.{owner}.{extension}"),
+    None => format!("{stem}.{owner}"),
+  };
+  path.with_file_name(file_name).display().to_string()
+}
+
+/// Writes the unified diff of the files of each owner to its own patch file (see `path_to_owner_patch`).
+pub(crate) fn write_patches_by_owner(
+  file_patches_by_owner: &BTreeMap<String, Vec<FilePatch>>, path_to_patch: &str,
+) {
+  for (owner, file_patches) in file_patches_by_owner {
+    write_patch(file_patches, &path_to_owner_patch(path_to_patch, owner));
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/ownership_test.rs"]
+mod ownership_test;
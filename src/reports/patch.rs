@@ -0,0 +1,331 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{fs, ops::Range};
+
+use getset::Getters;
+use itertools::Itertools;
+
+/// The number of unchanged lines shown around the changed lines of a hunk.
+const CONTEXT_LINES: usize = 3;
+
+/// An edited file, i.e. its path (relative to the code base) with its content before and after the cleanup.
+/// `updated` is `None` when the file was deleted.
+#[derive(Debug, Clone, Getters)]
+pub(crate) struct FilePatch {
+  #[get = "pub"]
+  path: String,
+  #[get = "pub"]
+  original: String,
+  #[get = "pub"]
+  updated: Option<String>,
+}
+
+impl FilePatch {
+  pub(crate) fn new(path: String, original: String, updated: Option<String>) -> Self {
+    Self {
+      path,
+      original,
+      updated,
+    }
+  }
+
+  /// Renders the (git-style) unified diff of this file.
+  /// The changed lines are reported in hunks (see `get_hunks`), along with (up to) `CONTEXT_LINES` unchanged lines around them.
+  /// Returns an empty string if the file is unchanged.
+  pub(crate) fn to_unified_diff(&self) -> String {
+    let (old_lines, new_lines) = self.get_lines();
+    let diff_lines = get_diff_lines(&old_lines, &new_lines);
+    let hunks = group_changes(&diff_lines);
+    if hunks.is_empty() && self.updated.is_some() {
+      return String::new();
+    }
+
+    let new_path = match self.updated {
+      Some(_) => format!("b/{}", self.path),
+      None => "/dev/null".to_string(),
+    };
+    let mut diff = vec![
+      format!("diff --git a/{} b/{}\n", self.path, self.path),
+      format!("--- a/{}\n", self.path),
+      format!("+++ {new_path}\n"),
+    ];
+    for (first, last) in hunks {
+      let hunk = &diff_lines
+        [first.saturating_sub(CONTEXT_LINES)..(last + CONTEXT_LINES + 1).min(diff_lines.len())];
+      let old_count = hunk.iter().filter(|l| l.marker != '+').count();
+      let new_count = hunk.iter().filter(|l| l.marker != '-').count();
+      diff.push(format!(
+        "@@ -{} +{} @@\n",
+        hunk_range(hunk[0].old_index, old_count),
+        hunk_range(hunk[0].new_index, new_count)
+      ));
+      diff.extend(hunk.iter().map(|l| diff_line(l.marker, l.line)));
+    }
+    diff.join("")
+  }
+
+  /// Returns the hunks of the diff of this file, i.e. the ranges of (zero-based) old lines replaced by new lines,
+  /// where the changes separated by more than twice `CONTEXT_LINES` unchanged lines are reported in separate hunks.
+  /// Unlike in the unified diff, the hunks do not include the unchanged lines around them.
+  pub(crate) fn get_hunks(&self) -> Vec<Hunk> {
+    let (old_lines, new_lines) = self.get_lines();
+    let diff_lines = get_diff_lines(&old_lines, &new_lines);
+    group_changes(&diff_lines)
+      .into_iter()
+      .map(|(first, last)| {
+        let hunk = &diff_lines[first..=last];
+        let (old_start, new_start) = (hunk[0].old_index, hunk[0].new_index);
+        Hunk {
+          old_lines: old_start..old_start + hunk.iter().filter(|l| l.marker != '+').count(),
+          new_lines: new_start..new_start + hunk.iter().filter(|l| l.marker != '-').count(),
+        }
+      })
+      .collect()
+  }
+
+  // The lines of the content before and after the cleanup (none after the deletion of the file).
+  fn get_lines(&self) -> (Vec<&str>, Vec<&str>) {
+    let old_lines = self.original.split_inclusive('\n').collect_vec();
+    let new_lines = self
+      .updated
+      .as_deref()
+      .unwrap_or_default()
+      .split_inclusive('\n')
+      .collect_vec();
+    (old_lines, new_lines)
+  }
+}
+
+/// A hunk of the diff of a file, i.e. the range of (zero-based) old lines replaced by the range of new lines.
+#[derive(Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct Hunk {
+  #[get = "pub"]
+  old_lines: Range<usize>,
+  #[get = "pub"]
+  new_lines: Range<usize>,
+}
+
+/// A line of the diff, along with the number of old and new lines preceding it.
+struct DiffLine<'a> {
+  marker: char,
+  line: &'a str,
+  old_index: usize,
+  new_index: usize,
+}
+
+/// Computes the lines of the diff turning `old_lines` into `new_lines`, i.e. the common lines (` `),
+/// the deleted lines (`-`) and the inserted lines (`+`) between them.
+fn get_diff_lines<'a>(old_lines: &[&'a str], new_lines: &[&'a str]) -> Vec<DiffLine<'a>> {
+  let mut diff_lines = vec![];
+  let (mut old_index, mut new_index) = (0, 0);
+  let common_lines = get_common_lines(old_lines, new_lines);
+  // The end of both files follows the changes after the last common line
+  for (old_end, new_end) in common_lines
+    .into_iter()
+    .chain([(old_lines.len(), new_lines.len())])
+  {
+    diff_lines.extend((old_index..old_end).map(|i| DiffLine {
+      marker: '-',
+      line: old_lines[i],
+      old_index: i,
+      new_index,
+    }));
+    diff_lines.extend((new_index..new_end).map(|j| DiffLine {
+      marker: '+',
+      line: new_lines[j],
+      old_index: old_end,
+      new_index: j,
+    }));
+    if let Some(&line) = old_lines.get(old_end) {
+      diff_lines.push(DiffLine {
+        marker: ' ',
+        line,
+        old_index: old_end,
+        new_index: new_end,
+      });
+    }
+    (old_index, new_index) = (old_end + 1, new_end + 1);
+  }
+  diff_lines
+}
+
+/// Groups the changed lines of `diff_lines` closer to each other than twice the context into hunks,
+/// i.e. the indices of the first and the last changed line of each hunk.
+fn group_changes(diff_lines: &[DiffLine]) -> Vec<(usize, usize)> {
+  let mut hunks: Vec<(usize, usize)> = vec![];
+  for change in diff_lines.iter().positions(|l| l.marker != ' ') {
+    match hunks.last_mut() {
+      Some((_, last)) if change - *last <= 2 * CONTEXT_LINES + 1 => *last = change,
+      _ => hunks.push((change, change)),
+    }
+  }
+  hunks
+}
+
+/// Computes the (indices of the) longest common subsequence of lines of `old_lines` and `new_lines`,
+/// with the linear space variant of the diff algorithm of Myers ("An O(ND) Difference Algorithm and Its Variations").
+fn get_common_lines(old_lines: &[&str], new_lines: &[&str]) -> Vec<(usize, usize)> {
+  let mut common_lines = vec![];
+  collect_common_lines(old_lines, new_lines, (0, 0), &mut common_lines);
+  common_lines
+}
+
+/// Collects the common lines of `old_lines` and `new_lines` (the lines of the files from `offsets` on) into `common_lines`.
+/// Apart from their common prefix and suffix, the lines are split around the middle snake of their diff (see `find_middle_snake`),
+/// and the lines before and after it are diffed recursively.
+fn collect_common_lines(
+  old_lines: &[&str], new_lines: &[&str], offsets: (usize, usize),
+  common_lines: &mut Vec<(usize, usize)>,
+) {
+  let prefix = old_lines
+    .iter()
+    .zip(new_lines)
+    .take_while(|(a, b)| a == b)
+    .count();
+  let (old_lines, new_lines) = (&old_lines[prefix..], &new_lines[prefix..]);
+  let (old_offset, new_offset) = (offsets.0 + prefix, offsets.1 + prefix);
+  let suffix = old_lines
+    .iter()
+    .rev()
+    .zip(new_lines.iter().rev())
+    .take_while(|(a, b)| a == b)
+    .count();
+  let (old_end, new_end) = (old_lines.len() - suffix, new_lines.len() - suffix);
+
+  common_lines.extend((0..prefix).map(|i| (offsets.0 + i, offsets.1 + i)));
+  // Since the remaining lines differ at both ends, their diff deletes or inserts at least two lines,
+  // hence the diffs before and after the middle snake are smaller.
+  if old_end > 0 && new_end > 0 {
+    let ((x, y), (u, v)) = find_middle_snake(&old_lines[..old_end], &new_lines[..new_end]);
+    collect_common_lines(
+      &old_lines[..x],
+      &new_lines[..y],
+      (old_offset, new_offset),
+      common_lines,
+    );
+    common_lines.extend((0..u - x).map(|i| (old_offset + x + i, new_offset + y + i)));
+    collect_common_lines(
+      &old_lines[u..old_end],
+      &new_lines[v..new_end],
+      (old_offset + u, new_offset + v),
+      common_lines,
+    );
+  }
+  common_lines.extend((0..suffix).map(|i| (old_offset + old_end + i, new_offset + new_end + i)));
+}
+
+/// Finds the middle snake of the diff of `old_lines` and `new_lines`, i.e. the diagonal (of common lines) from `(x, y)` to `(u, v)`
+/// where the furthest paths searched from the start and from the end of the files first overlap. It halves a shortest path.
+/// Only the furthest `x` reached on each diagonal is kept (for the current number of edits), hence the linear space.
+fn find_middle_snake(old_lines: &[&str], new_lines: &[&str]) -> ((usize, usize), (usize, usize)) {
+  let (n, m) = (old_lines.len() as isize, new_lines.len() as isize);
+  let max_d = (n + m + 1) / 2;
+  // The furthest `x` reached on each diagonal `k = x - y` (offset by `max_d + 1`), from the start of the files
+  // and from their end (i.e. in the reversed files, where the diagonal `k` is the diagonal `delta - k` of the files).
+  let offset = max_d + 1;
+  let mut forward = vec![0; 2 * offset as usize + 1];
+  let mut backward = vec![0; 2 * offset as usize + 1];
+  let delta = n - m;
+  // The paths can only overlap after a forward (resp. backward) search if `delta` is odd (resp. even)
+  let odd = delta % 2 != 0;
+  let snake = |(x, y): (isize, isize), (u, v): (isize, isize)| {
+    ((x as usize, y as usize), (u as usize, v as usize))
+  };
+
+  for d in 0..=max_d {
+    for k in (-d..=d).step_by(2) {
+      let (start, end) = extend_path(&mut forward, offset, k, d, |x, y| {
+        x < n && y < m && old_lines[x as usize] == new_lines[y as usize]
+      });
+      let reversed_k = delta - k;
+      if odd && reversed_k.abs() < d && end + backward[(reversed_k + offset) as usize] >= n {
+        return snake((start, start - k), (end, end - k));
+      }
+    }
+    for k in (-d..=d).step_by(2) {
+      let (start, end) = extend_path(&mut backward, offset, k, d, |x, y| {
+        x < n && y < m && old_lines[(n - 1 - x) as usize] == new_lines[(m - 1 - y) as usize]
+      });
+      let forward_k = delta - k;
+      if !odd && forward_k.abs() <= d && end + forward[(forward_k + offset) as usize] >= n {
+        return snake((n - end, m - end + k), (n - start, m - start + k));
+      }
+    }
+  }
+  unreachable!("The paths searched from both ends of the files always overlap")
+}
+
+/// Extends the furthest path reaching the diagonal `k` with `d` edits along the common lines (as per `is_common`),
+/// given the `furthest` `x` reached on each diagonal with `d - 1` edits (offset by `offset`), and records its `x`.
+/// Returns the `x` of the start and of the end of its (last) diagonal.
+fn extend_path(
+  furthest: &mut [isize], offset: isize, k: isize, d: isize,
+  is_common: impl Fn(isize, isize) -> bool,
+) -> (isize, isize) {
+  let start = if comes_from_insertion(|k| furthest[(k + offset) as usize], k, d) {
+    furthest[(k + 1 + offset) as usize]
+  } else {
+    furthest[(k - 1 + offset) as usize] + 1
+  };
+  let mut x = start;
+  while is_common(x, x - k) {
+    x += 1;
+  }
+  furthest[(k + offset) as usize] = x;
+  (start, x)
+}
+
+/// Whether the furthest path reaching the diagonal `k` with `d` edits comes from the diagonal `k + 1` (i.e. an insertion),
+/// given the `furthest` `x` reached on each diagonal with `d - 1` edits.
+fn comes_from_insertion(furthest: impl Fn(isize) -> isize, k: isize, d: isize) -> bool {
+  k == -d || (k != d && furthest(k - 1) < furthest(k + 1))
+}
+
+/// Renders the range `start,count` of a hunk header.
+/// As per the unified diff format, an empty range starts at the line before the hunk.
+fn hunk_range(start: usize, count: usize) -> String {
+  if count == 0 {
+    format!("{start},0")
+  } else {
+    format!("{},{count}", start + 1)
+  }
+}
+
+fn diff_line(marker: char, line: &str) -> String {
+  if line.ends_with('\n') {
+    format!("{marker}{line}")
+  } else {
+    format!("{marker}{line}\n\\ No newline at end of file\n")
+  }
+}
+
+/// Renders the unified diff of all the given files (sorted by path).
+pub(crate) fn to_patch(file_patches: &[FilePatch]) -> String {
+  file_patches
+    .iter()
+    .sorted_by(|a, b| a.path.cmp(&b.path))
+    .map(FilePatch::to_unified_diff)
+    .join("")
+}
+
+/// Writes the unified diff of all the given files to `path_to_patch`.
+pub(crate) fn write_patch(file_patches: &[FilePatch], path_to_patch: &String) {
+  if fs::write(path_to_patch, to_patch(file_patches)).is_err() {
+    panic!("Could not write the patch to the file - {path_to_patch}");
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/patch_test.rs"]
+mod patch_test;
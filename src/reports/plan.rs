@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{
+  fs,
+  path::{Path, PathBuf},
+};
+
+use getset::Getters;
+use serde_derive::{Deserialize, Serialize};
+
+use super::{manifest::RunManifest, patch::FilePatch};
+
+/// The edits planned by a Piranha run (see `plan_piranha`), i.e. the content of each edited file before and after the cleanup.
+/// Once reviewed, the plan is applied as is (see `apply_plan`), without running the rules again.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct EditPlan {
+  // The code base the paths of the files are relative to
+  #[get = "pub"]
+  path_to_codebase: String,
+  // The edited files (sorted by path)
+  #[get = "pub"]
+  files: Vec<PlannedEdit>,
+  // What the run depends on, to reproduce it (see `RunManifest`)
+  #[get = "pub"]
+  #[serde(default, skip_serializing_if = "Option::is_none")]
+  manifest: Option<RunManifest>,
+}
+
+/// The planned edit of a file.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct PlannedEdit {
+  // The path of the file (relative to the code base)
+  #[get = "pub"]
+  path: String,
+  // The content of the file the edit was planned against
+  #[get = "pub"]
+  original: String,
+  // The content of the file after the edit, `None` if the file is deleted
+  #[get = "pub"]
+  updated: Option<String>,
+}
+
+impl EditPlan {
+  /// Plans the edits of the given files (the unchanged files are omitted).
+  pub(crate) fn new(path_to_codebase: String, file_patches: &[FilePatch]) -> Self {
+    let mut files = file_patches
+      .iter()
+      .filter(|p| p.updated().as_ref() != Some(p.original()))
+      .map(|p| PlannedEdit {
+        path: p.path().to_string(),
+        original: p.original().to_string(),
+        updated: p.updated().clone(),
+      })
+      .collect::<Vec<_>>();
+    files.sort_by(|a, b| a.path.cmp(&b.path));
+    Self {
+      path_to_codebase,
+      files,
+      manifest: None,
+    }
+  }
+
+  /// Embeds the manifest of the run the plan was made by.
+  pub(crate) fn with_manifest(self, manifest: RunManifest) -> Self {
+    Self {
+      manifest: Some(manifest),
+      ..self
+    }
+  }
+
+  /// Reads the plan from the Json file `path_to_plan`.
+  pub(crate) fn read(path_to_plan: &str) -> Self {
+    read_json(path_to_plan, "the edit plan")
+  }
+
+  /// Writes the plan to the Json file `path_to_plan`.
+  pub(crate) fn write(&self, path_to_plan: &str) {
+    write_json(self, path_to_plan, "the edit plan");
+  }
+
+  /// Returns the paths of the files whose content changed since the plan was made (e.g. a file edited after the review).
+  pub(crate) fn get_conflicts(&self) -> Vec<String> {
+    get_conflicts(&self.path_to_codebase, &self.files)
+  }
+
+  /// Writes the planned content of each file byte-for-byte (or deletes it).
+  /// Note that the conflicts are not checked (see `get_conflicts`).
+  pub(crate) fn apply(&self) {
+    apply_edits(&self.path_to_codebase, &self.files, "the edit plan");
+  }
+}
+
+impl RecordedEdit for PlannedEdit {
+  fn path(&self) -> &String {
+    &self.path
+  }
+
+  fn is_conflict(&self, content: Option<&String>) -> bool {
+    content != Some(&self.original)
+  }
+
+  fn get_updated(&self, _content: String) -> Option<String> {
+    self.updated.clone()
+  }
+}
+
+/// The edit of a file recorded by a Piranha run, to be applied at a later time (e.g. `PlannedEdit` or `QuickFix`).
+pub(crate) trait RecordedEdit {
+  /// The path of the file (relative to the code base).
+  fn path(&self) -> &String;
+  /// Whether the current `content` of the file (`None` if it does not exist) is not the one the edit was recorded against.
+  fn is_conflict(&self, content: Option<&String>) -> bool;
+  /// Returns the content of the file after the edit given its current `content`, `None` if the file is deleted.
+  fn get_updated(&self, content: String) -> Option<String>;
+}
+
+/// Reads the Json file `path` (e.g. an edit plan), `description` naming its content in the error message.
+pub(crate) fn read_json<T: serde::de::DeserializeOwned>(path: &str, description: &str) -> T {
+  fs::read_to_string(path)
+    .ok()
+    .and_then(|content| serde_json::from_str(&content).ok())
+    .unwrap_or_else(|| panic!("Could not read {description} from the file - {path}"))
+}
+
+/// Writes `value` to the Json file `path`, `description` naming it in the error message.
+pub(crate) fn write_json<T: serde::Serialize>(value: &T, path: &str, description: &str) {
+  if let Ok(contents) = serde_json::to_string_pretty(value) {
+    if fs::write(path, contents).is_ok() {
+      return;
+    }
+  }
+  panic!("Could not write {description} to the file - {path}");
+}
+
+/// Returns the paths of the files of `edits` whose current content conflicts with the edit (see `RecordedEdit::is_conflict`).
+pub(crate) fn get_conflicts<E: RecordedEdit>(path_to_codebase: &str, edits: &[E]) -> Vec<String> {
+  edits
+    .iter()
+    .filter(|edit| {
+      let content = fs::read_to_string(absolute_path(path_to_codebase, edit)).ok();
+      edit.is_conflict(content.as_ref())
+    })
+    .map(|edit| edit.path().to_string())
+    .collect()
+}
+
+/// Applies each of `edits` to its file (or deletes it), `description` naming them in the error message.
+pub(crate) fn apply_edits<E: RecordedEdit>(path_to_codebase: &str, edits: &[E], description: &str) {
+  for edit in edits {
+    let path = absolute_path(path_to_codebase, edit);
+    let applied = fs::read_to_string(&path).and_then(|content| match edit.get_updated(content) {
+      Some(updated) => fs::write(&path, updated),
+      None => fs::remove_file(&path),
+    });
+    if applied.is_err() {
+      panic!(
+        "Could not apply {description} to the file - {}",
+        path.display()
+      );
+    }
+  }
+}
+
+// The files outside the code base are recorded by their absolute path.
+fn absolute_path(path_to_codebase: &str, edit: &impl RecordedEdit) -> PathBuf {
+  Path::new(path_to_codebase).join(edit.path())
+}
+
+#[cfg(test)]
+#[path = "unit_tests/plan_test.rs"]
+mod plan_test;
@@ -0,0 +1,193 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use getset::Getters;
+use serde_derive::{Deserialize, Serialize};
+
+use super::{
+  manifest::{get_sha256, RunManifest},
+  patch::{FilePatch, Hunk},
+  plan::{apply_edits, get_conflicts, read_json, write_json, RecordedEdit},
+};
+
+/// The edits computed by a Piranha run as machine-applicable fixes, i.e. the ranges of each edited file to replace
+/// (one per hunk of the patch) along with their replacement, and the checksum of the content the ranges refer to.
+/// Unlike the patch, the bundle is applied by other tools (e.g. editors) at a later time,
+/// which should refuse to apply the fixes of a file whose checksum changed since the analysis.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct QuickFixBundle {
+  // The code base the paths of the files are relative to
+  #[get = "pub"]
+  path_to_codebase: String,
+  // The fixes of the edited files (sorted by path)
+  #[get = "pub"]
+  fixes: Vec<QuickFix>,
+  // What the run depends on, to reproduce it (see `RunManifest`)
+  #[get = "pub"]
+  #[serde(default, skip_serializing_if = "Option::is_none")]
+  manifest: Option<RunManifest>,
+}
+
+/// The fixes of a file.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct QuickFix {
+  // The path of the file (relative to the code base)
+  #[get = "pub"]
+  path: String,
+  // The checksum (SHA-256, in hexadecimal) of the content of the file at the analysis
+  #[get = "pub"]
+  checksum: String,
+  // The edits of the file, whose ranges refer to the content at the analysis (empty if the file is deleted)
+  #[get = "pub"]
+  edits: Vec<QuickFixEdit>,
+  // Whether the file is deleted (instead of edited)
+  #[get = "pub"]
+  deleted: bool,
+}
+
+/// The replacement of a range of the file.
+/// The rows and the columns (in bytes) are zero-based, as the byte offsets.
+#[derive(Serialize, Deserialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct QuickFixEdit {
+  #[get = "pub"]
+  start_byte: usize,
+  #[get = "pub"]
+  end_byte: usize,
+  #[get = "pub"]
+  start_row: usize,
+  #[get = "pub"]
+  start_column: usize,
+  #[get = "pub"]
+  end_row: usize,
+  #[get = "pub"]
+  end_column: usize,
+  #[get = "pub"]
+  replacement: String,
+}
+
+impl QuickFixEdit {
+  /// The edit replacing the old lines of the `hunk` of `original` by its new lines of `updated`.
+  fn new(original: &str, updated: &str, hunk: &Hunk) -> Self {
+    let start_byte = get_line_offset(original, hunk.old_lines().start);
+    let end_byte = get_line_offset(original, hunk.old_lines().end);
+    let (start_row, start_column) = get_position(original, start_byte);
+    let (end_row, end_column) = get_position(original, end_byte);
+    Self {
+      start_byte,
+      end_byte,
+      start_row,
+      start_column,
+      end_row,
+      end_column,
+      replacement: updated[get_line_offset(updated, hunk.new_lines().start)
+        ..get_line_offset(updated, hunk.new_lines().end)]
+        .to_string(),
+    }
+  }
+}
+
+/// Returns the byte offset of the (zero-based) `line` of `content`, its length past the last line.
+fn get_line_offset(content: &str, line: usize) -> usize {
+  content.split_inclusive('\n').take(line).map(str::len).sum()
+}
+
+/// Returns the (zero-based) row and column of the byte `offset` of `content`.
+fn get_position(content: &str, offset: usize) -> (usize, usize) {
+  let before = &content[..offset];
+  let row = before.matches('\n').count();
+  let column = before.rfind('\n').map_or(offset, |i| offset - i - 1);
+  (row, column)
+}
+
+impl QuickFixBundle {
+  /// Bundles the fixes of the given files (the unchanged files are omitted).
+  pub(crate) fn new(path_to_codebase: String, file_patches: &[FilePatch]) -> Self {
+    let mut fixes = file_patches
+      .iter()
+      .filter(|p| p.updated().as_ref() != Some(p.original()))
+      .map(|p| QuickFix {
+        path: p.path().to_string(),
+        checksum: get_sha256(p.original()),
+        edits: match p.updated() {
+          Some(updated) => p
+            .get_hunks()
+            .iter()
+            .map(|hunk| QuickFixEdit::new(p.original(), updated, hunk))
+            .collect(),
+          None => vec![],
+        },
+        deleted: p.updated().is_none(),
+      })
+      .collect::<Vec<_>>();
+    fixes.sort_by(|a, b| a.path.cmp(&b.path));
+    Self {
+      path_to_codebase,
+      fixes,
+      manifest: None,
+    }
+  }
+
+  /// Embeds the manifest of the run the fixes were computed by.
+  pub(crate) fn with_manifest(self, manifest: RunManifest) -> Self {
+    Self {
+      manifest: Some(manifest),
+      ..self
+    }
+  }
+
+  /// Reads the bundle from the Json file `path_to_bundle`.
+  pub(crate) fn read(path_to_bundle: &str) -> Self {
+    read_json(path_to_bundle, "the quick-fix bundle")
+  }
+
+  /// Writes the bundle to the Json file `path_to_bundle`.
+  pub(crate) fn write(&self, path_to_bundle: &str) {
+    write_json(self, path_to_bundle, "the quick-fix bundle");
+  }
+
+  /// Returns the paths of the files whose checksum changed since the analysis (or that no longer exist).
+  pub(crate) fn get_conflicts(&self) -> Vec<String> {
+    get_conflicts(&self.path_to_codebase, &self.fixes)
+  }
+
+  /// Applies the edits of each file (or deletes it).
+  /// Note that the conflicts are not checked (see `get_conflicts`).
+  pub(crate) fn apply(&self) {
+    apply_edits(&self.path_to_codebase, &self.fixes, "the quick-fix bundle");
+  }
+}
+
+impl RecordedEdit for QuickFix {
+  fn path(&self) -> &String {
+    &self.path
+  }
+
+  fn is_conflict(&self, content: Option<&String>) -> bool {
+    content.map_or(true, |content| get_sha256(content) != self.checksum)
+  }
+
+  fn get_updated(&self, mut content: String) -> Option<String> {
+    if self.deleted {
+      return None;
+    }
+    // The ranges refer to the content at the analysis, hence the edits are applied from the bottom up
+    for edit in self.edits.iter().rev() {
+      content.replace_range(edit.start_byte..edit.end_byte, &edit.replacement);
+    }
+    Some(content)
+  }
+}
+
+#[cfg(test)]
+#[path = "unit_tests/quick_fix_test.rs"]
+mod quick_fix_test;
@@ -0,0 +1,225 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use getset::Getters;
+use serde_derive::Serialize;
+
+use super::manifest::RunManifest;
+
+/// Whether Piranha analyzed all the relevant files, was interrupted by an internal error,
+/// or reached its `deadline` (the remaining files are written to the checkpoint).
+#[derive(Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "lowercase")]
+pub(crate) enum RunStatus {
+  Complete,
+  Partial,
+  Checkpointed,
+}
+
+/// Summarizes a Piranha run, so that automation can retry only the remaining portion of a partial run.
+#[derive(Serialize, Debug, Clone, Getters)]
+pub(crate) struct RunReport {
+  #[get = "pub"]
+  status: RunStatus,
+  // The internal error that interrupted the run (if any)
+  #[get = "pub"]
+  error: Option<String>,
+  // The file being processed when the run was interrupted (its edits are discarded)
+  #[get = "pub"]
+  failed_file: Option<String>,
+  // Files updated by Piranha (i.e. included in the patch)
+  #[get = "pub"]
+  updated_files: Vec<String>,
+  // Files that still have to be processed (including the failed file, if any)
+  #[get = "pub"]
+  remaining_files: Vec<String>,
+  // The identifiers renamed after the cleanup (only with `renames`)
+  #[get = "pub"]
+  #[serde(skip_serializing_if = "Vec::is_empty")]
+  renamed_identifiers: Vec<RenamedIdentifier>,
+  // The functions skipped since they use a syntax the grammar does not support (the rest of their file is cleaned up)
+  #[get = "pub"]
+  #[serde(skip_serializing_if = "Vec::is_empty")]
+  skipped_functions: Vec<UnsupportedFunction>,
+  // What the run depends on, to reproduce it (see `RunManifest`)
+  #[get = "pub"]
+  #[serde(skip_serializing_if = "Option::is_none")]
+  manifest: Option<RunManifest>,
+}
+
+/// An identifier renamed after the cleanup, along with the files it was renamed in.
+#[derive(Serialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct RenamedIdentifier {
+  #[get = "pub"]
+  from: String,
+  #[get = "pub"]
+  to: String,
+  // The number of renamed occurrences (i.e. the declaration and the usages)
+  #[get = "pub"]
+  occurrences: usize,
+  #[get = "pub"]
+  files: Vec<String>,
+  // Why the rename was refused (e.g. the new name is already used by the package), if it was
+  #[get = "pub"]
+  #[serde(skip_serializing_if = "Option::is_none")]
+  refusal: Option<String>,
+}
+
+impl RenamedIdentifier {
+  pub(crate) fn new(from: String, to: String, occurrences: usize, files: Vec<String>) -> Self {
+    Self {
+      from,
+      to,
+      occurrences,
+      files,
+      refusal: None,
+    }
+  }
+
+  /// A rename refused by Piranha, since it would not compile (e.g. it would redeclare an identifier).
+  pub(crate) fn refused(from: String, to: String, refusal: String) -> Self {
+    Self {
+      from,
+      to,
+      occurrences: 0,
+      files: vec![],
+      refusal: Some(refusal),
+    }
+  }
+}
+
+/// A function skipped by Piranha, since it uses a syntax the grammar does not support (e.g. a syntax newer than the bundled grammar).
+#[derive(Serialize, Debug, Clone, Getters, PartialEq, Eq)]
+pub(crate) struct UnsupportedFunction {
+  // The path of the file (relative to the code base)
+  #[get = "pub"]
+  path: String,
+  #[get = "pub"]
+  function: String,
+  // The (1-based) position of the function
+  #[get = "pub"]
+  line: usize,
+  #[get = "pub"]
+  column: usize,
+  // Why the function was skipped, i.e. the unsupported construct and its position
+  #[get = "pub"]
+  reason: String,
+}
+
+impl UnsupportedFunction {
+  pub(crate) fn new(
+    path: String, function: String, line: usize, column: usize, reason: String,
+  ) -> Self {
+    Self {
+      path,
+      function,
+      line,
+      column,
+      reason,
+    }
+  }
+}
+
+impl RunReport {
+  pub(crate) fn complete(updated_files: Vec<String>) -> Self {
+    Self {
+      status: RunStatus::Complete,
+      error: None,
+      failed_file: None,
+      updated_files,
+      remaining_files: vec![],
+      renamed_identifiers: vec![],
+      skipped_functions: vec![],
+      manifest: None,
+    }
+  }
+
+  pub(crate) fn partial(
+    error: String, failed_file: String, updated_files: Vec<String>, remaining_files: Vec<String>,
+  ) -> Self {
+    Self {
+      status: RunStatus::Partial,
+      error: Some(error),
+      failed_file: Some(failed_file),
+      updated_files,
+      remaining_files,
+      renamed_identifiers: vec![],
+      skipped_functions: vec![],
+      manifest: None,
+    }
+  }
+
+  /// The `deadline` was reached before processing the `remaining_files` (the processed files are edited).
+  pub(crate) fn checkpointed(updated_files: Vec<String>, remaining_files: Vec<String>) -> Self {
+    Self {
+      status: RunStatus::Checkpointed,
+      error: None,
+      failed_file: None,
+      updated_files,
+      remaining_files,
+      renamed_identifiers: vec![],
+      skipped_functions: vec![],
+      manifest: None,
+    }
+  }
+
+  /// Lists the identifiers renamed after the cleanup.
+  pub(crate) fn with_renamed_identifiers(
+    self, renamed_identifiers: Vec<RenamedIdentifier>,
+  ) -> Self {
+    Self {
+      renamed_identifiers,
+      ..self
+    }
+  }
+
+  /// Lists the functions skipped since they use a syntax the grammar does not support.
+  pub(crate) fn with_skipped_functions(self, skipped_functions: Vec<UnsupportedFunction>) -> Self {
+    Self {
+      skipped_functions,
+      ..self
+    }
+  }
+
+  /// Embeds the manifest of the run.
+  pub(crate) fn with_manifest(self, manifest: RunManifest) -> Self {
+    Self {
+      manifest: Some(manifest),
+      ..self
+    }
+  }
+
+  pub(crate) fn is_partial(&self) -> bool {
+    self.status == RunStatus::Partial
+  }
+
+  pub(crate) fn is_checkpointed(&self) -> bool {
+    self.status == RunStatus::Checkpointed
+  }
+}
+
+/// Writes the run report to the Json file `path_to_run_report`.
+pub(crate) fn write_run_report(run_report: &RunReport, path_to_run_report: &String) {
+  if let Ok(contents) = serde_json::to_string_pretty(run_report) {
+    if fs::write(path_to_run_report, contents).is_ok() {
+      return;
+    }
+  }
+  panic!("Could not write the run report to the file - {path_to_run_report}");
+}
+
+#[cfg(test)]
+#[path = "unit_tests/run_report_test.rs"]
+mod run_report_test;
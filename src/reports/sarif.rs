@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use getset::Getters;
+use itertools::Itertools;
+use serde_json::{json, Value};
+use tree_sitter::Range;
+
+use super::manifest::RunManifest;
+
+const SARIF_VERSION: &str = "2.1.0";
+const SARIF_SCHEMA: &str = "https://json.schemastore.org/sarif-2.1.0.json";
+
+/// An occurrence of a "match-only" rule, reported as a SARIF result.
+#[derive(Debug, Clone, Getters)]
+pub(crate) struct SarifResult {
+  // Name of the rule that matched
+  #[get = "pub"]
+  rule_id: String,
+  // Path of the file (relative to the code base)
+  #[get = "pub"]
+  path: String,
+  #[get = "pub"]
+  matched_string: String,
+  #[get = "pub"]
+  range: Range,
+}
+
+impl SarifResult {
+  pub(crate) fn new(rule_id: String, path: String, matched_string: String, range: Range) -> Self {
+    Self {
+      rule_id,
+      path,
+      matched_string,
+      range,
+    }
+  }
+
+  fn to_json(&self) -> Value {
+    // SARIF lines and columns are 1-based, while tree-sitter points are 0-based
+    json!({
+      "ruleId": self.rule_id,
+      "level": "warning",
+      "message": {
+        "text": format!("`{}` matches the rule `{}`", self.matched_string, self.rule_id)
+      },
+      "locations": [{
+        "physicalLocation": {
+          "artifactLocation": { "uri": self.path },
+          "region": {
+            "startLine": self.range.start_point.row + 1,
+            "startColumn": self.range.start_point.column + 1,
+            "endLine": self.range.end_point.row + 1,
+            "endColumn": self.range.end_point.column + 1
+          }
+        }
+      }]
+    })
+  }
+}
+
+/// Renders the results as a SARIF log (with a single run of the tool `piranha`).
+/// The rules of the tool are the (distinct) rules of the results.
+pub(crate) fn to_sarif(results: &[SarifResult]) -> Value {
+  let rules = results
+    .iter()
+    .map(|r| r.rule_id.as_str())
+    .unique()
+    .sorted()
+    .map(|id| json!({ "id": id }))
+    .collect_vec();
+  json!({
+    "$schema": SARIF_SCHEMA,
+    "version": SARIF_VERSION,
+    "runs": [{
+      "tool": {
+        "driver": {
+          "name": "piranha",
+          "informationUri": "https://github.com/uber/piranha",
+          "rules": rules
+        }
+      },
+      "results": results.iter().map(SarifResult::to_json).collect_vec()
+    }]
+  })
+}
+
+/// Embeds the manifest in the property bag of the run, and sets the version of the tool accordingly.
+pub(crate) fn with_manifest(mut sarif: Value, manifest: &RunManifest) -> Value {
+  sarif["runs"][0]["tool"]["driver"]["version"] = json!(manifest.piranha_version());
+  sarif["runs"][0]["properties"] = json!({ "manifest": manifest });
+  sarif
+}
+
+/// Writes the SARIF report (along with the manifest of the run) to the file `path_to_sarif_report`.
+pub(crate) fn write_sarif_report(
+  results: &[SarifResult], manifest: &RunManifest, path_to_sarif_report: &String,
+) {
+  let sarif = with_manifest(to_sarif(results), manifest);
+  if let Ok(contents) = serde_json::to_string_pretty(&sarif) {
+    if fs::write(path_to_sarif_report, contents).is_ok() {
+      return;
+    }
+  }
+  panic!("Could not write the SARIF report to the file - {path_to_sarif_report}");
+}
+
+#[cfg(test)]
+#[path = "unit_tests/sarif_test.rs"]
+mod sarif_test;
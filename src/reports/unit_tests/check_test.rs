@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use tempdir::TempDir;
+
+use super::get_mismatches;
+use crate::models::{default_configs::GO, language::PiranhaLanguage};
+use crate::reports::patch::FilePatch;
+
+#[test]
+fn test_get_mismatches() {
+  let codebase = TempDir::new("codebase").unwrap();
+  let expected = TempDir::new("expected").unwrap();
+  let write = |dir: &TempDir, path: &str, content: &str| {
+    let path = dir.path().join(path);
+    fs::create_dir_all(path.parent().unwrap()).unwrap();
+    fs::write(path, content).unwrap();
+  };
+  // Edited as expected (modulo whitespace)
+  write(&codebase, "pkg/a.go", "if true {}\n");
+  write(&expected, "pkg/a.go", "after  \n");
+  // Unchanged, but expected to be edited
+  write(&codebase, "b.go", "unchanged\n");
+  write(&expected, "b.go", "edited\n");
+  // Deleted as expected
+  write(&codebase, "c.go", "deleted\n");
+  // Deleted, but expected to be kept
+  write(&codebase, "d.go", "kept\n");
+  write(&expected, "d.go", "kept\n");
+  // Not a Go file
+  write(&codebase, "README.md", "readme\n");
+
+  let file_patches = vec![
+    FilePatch::new(
+      "pkg/a.go".to_string(),
+      "if true {}\n".to_string(),
+      Some("after\n".to_string()),
+    ),
+    FilePatch::new("c.go".to_string(), "deleted\n".to_string(), None),
+    FilePatch::new("d.go".to_string(), "kept\n".to_string(), None),
+  ];
+
+  let mismatches = get_mismatches(
+    &file_patches,
+    codebase.path().to_str().unwrap(),
+    expected.path().to_str().unwrap(),
+    &PiranhaLanguage::from(GO),
+  );
+
+  let paths = mismatches
+    .iter()
+    .map(|m| m.path().as_str())
+    .collect::<Vec<_>>();
+  assert_eq!(paths, vec!["b.go", "d.go"]);
+  assert_eq!(mismatches[0].original(), "edited\n");
+  assert_eq!(mismatches[0].updated().as_deref(), Some("unchanged\n"));
+  assert!(mismatches[1].updated().is_none());
+}
+
+#[test]
+fn test_get_mismatches_identical() {
+  let codebase = TempDir::new("codebase").unwrap();
+  fs::write(codebase.path().join("a.go"), "package a\n").unwrap();
+
+  let mismatches = get_mismatches(
+    &[],
+    codebase.path().to_str().unwrap(),
+    codebase.path().to_str().unwrap(),
+    &PiranhaLanguage::from(GO),
+  );
+  assert!(mismatches.is_empty());
+}
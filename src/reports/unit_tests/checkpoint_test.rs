@@ -0,0 +1,53 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::collections::HashMap;
+
+use tempdir::TempDir;
+
+use super::{Checkpoint, CheckpointedRule};
+
+#[test]
+fn test_checkpoint_round_trip() {
+  let checkpoint = Checkpoint::new(
+    "/code/base".to_string(),
+    vec!["pkg/b.go".to_string(), "pkg/c.go".to_string()],
+    vec![CheckpointedRule::new(
+      "delete_flag_declaration".to_string(),
+      HashMap::from([("flag_name".to_string(), "newCheckoutFlow".to_string())]),
+    )],
+    HashMap::from([(
+      "pkg".to_string(),
+      vec![CheckpointedRule::new(
+        "replace_package_nested_struct_field_read".to_string(),
+        HashMap::from([("struct_variable".to_string(), "settings".to_string())]),
+      )],
+    )]),
+    HashMap::from([("flag_name".to_string(), "newCheckoutFlow".to_string())]),
+  );
+
+  let temp_dir = TempDir::new("checkpoint").unwrap();
+  let path = temp_dir.path().join("checkpoint.json");
+  checkpoint.write(path.to_str().unwrap());
+
+  assert_eq!(Checkpoint::read(path.to_str().unwrap()), checkpoint);
+}
+
+#[test]
+#[should_panic(expected = "Could not read the checkpoint from the file")]
+fn test_checkpoint_read_invalid() {
+  let temp_dir = TempDir::new("checkpoint").unwrap();
+  let path = temp_dir.path().join("checkpoint.json");
+  std::fs::write(&path, "{}").unwrap();
+  Checkpoint::read(path.to_str().unwrap());
+}
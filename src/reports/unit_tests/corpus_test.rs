@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::{collections::HashMap, fs};
+
+use tempdir::TempDir;
+
+use super::{next_case_path, write_corpus_case, SUBSTITUTIONS_FILE};
+use crate::reports::patch::FilePatch;
+
+#[test]
+fn test_write_corpus_case() {
+  let configurations = TempDir::new("configurations").unwrap();
+  fs::write(configurations.path().join("rules.toml"), "[[rules]]\n").unwrap();
+  let corpus = TempDir::new("corpus").unwrap();
+  let path_to_corpus = corpus.path().to_str().unwrap().to_string();
+
+  let file_patches = vec![
+    FilePatch::new(
+      "pkg/a.go".to_string(),
+      "before\n".to_string(),
+      Some("after\n".to_string()),
+    ),
+    FilePatch::new(
+      "b.go".to_string(),
+      "same\n".to_string(),
+      Some("same\n".to_string()),
+    ),
+    FilePatch::new("c.go".to_string(), "deleted\n".to_string(), None),
+  ];
+  let substitutions = HashMap::from([("stale_flag".to_string(), "FLAG".to_string())]);
+
+  let case = write_corpus_case(
+    &file_patches,
+    configurations.path().to_str().unwrap(),
+    &substitutions,
+    &path_to_corpus,
+  )
+  .unwrap();
+
+  assert_eq!(case, corpus.path().join("case_1"));
+  let read = |p: &str| fs::read_to_string(case.join(p)).unwrap();
+  assert_eq!(read("input/pkg/a.go"), "before\n");
+  assert_eq!(read("expected/pkg/a.go"), "after\n");
+  assert_eq!(read("input/c.go"), "deleted\n");
+  assert!(!case.join("expected/c.go").exists());
+  assert!(!case.join("input/b.go").exists());
+  assert_eq!(read("configurations/rules.toml"), "[[rules]]\n");
+  assert!(!case.join("configurations/edges.toml").exists());
+  assert_eq!(
+    read(&format!("configurations/{SUBSTITUTIONS_FILE}")),
+    "[substitutions]\nstale_flag = \"FLAG\"\n"
+  );
+
+  // The next run is recorded as a new case
+  assert_eq!(next_case_path(corpus.path()), corpus.path().join("case_2"));
+}
+
+#[test]
+fn test_write_corpus_case_without_edits() {
+  let corpus = TempDir::new("corpus").unwrap();
+  let file_patches = vec![FilePatch::new(
+    "b.go".to_string(),
+    "same\n".to_string(),
+    Some("same\n".to_string()),
+  )];
+
+  let case = write_corpus_case(
+    &file_patches,
+    "",
+    &HashMap::new(),
+    &corpus.path().to_str().unwrap().to_string(),
+  );
+
+  assert!(case.is_none());
+  assert_eq!(fs::read_dir(corpus.path()).unwrap().count(), 0);
+}
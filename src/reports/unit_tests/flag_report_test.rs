@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use super::{get_flag_cleanups, FlagEdit};
+
+fn edit(rule: &str, line: usize) -> FlagEdit {
+  FlagEdit::new(
+    rule.to_string(),
+    line,
+    "matched".to_string(),
+    "".to_string(),
+  )
+}
+
+#[test]
+fn test_get_flag_cleanups() {
+  let edits = vec![
+    (
+      Some("stale_b".to_string()),
+      "payments/api.go".to_string(),
+      edit("replace_call", 3),
+    ),
+    (
+      Some("stale_a".to_string()),
+      "payments/api.go".to_string(),
+      edit("replace_call", 7),
+    ),
+    (
+      Some("stale_a".to_string()),
+      "payments/api.go".to_string(),
+      edit("simplify_if_statement_true", 7),
+    ),
+    (
+      None,
+      "main.go".to_string(),
+      edit("delete_empty_test_function", 1),
+    ),
+    (
+      Some("stale_a".to_string()),
+      "main.go".to_string(),
+      edit("replace_call", 12),
+    ),
+    (
+      Some("stale_a".to_string()),
+      "payments/client.go".to_string(),
+      edit("replace_call", 5),
+    ),
+  ];
+
+  let flag_cleanups = get_flag_cleanups(edits);
+
+  let flags = flag_cleanups
+    .iter()
+    .map(|f| (f.flag().clone(), *f.files(), *f.edits()))
+    .collect::<Vec<_>>();
+  assert_eq!(
+    flags,
+    vec![
+      (Some("stale_a".to_string()), 3, 4),
+      (Some("stale_b".to_string()), 1, 1),
+      (None, 1, 1),
+    ]
+  );
+
+  let stale_a = &flag_cleanups[0];
+  let packages = stale_a
+    .packages()
+    .iter()
+    .map(|p| {
+      let files = p
+        .files()
+        .iter()
+        .map(|f| f.path().as_str())
+        .collect::<Vec<_>>();
+      (p.package().as_str(), files)
+    })
+    .collect::<Vec<_>>();
+  assert_eq!(
+    packages,
+    vec![
+      (".", vec!["main.go"]),
+      ("payments", vec!["payments/api.go", "payments/client.go"]),
+    ]
+  );
+  // The edits of a file are kept in the order of application
+  let rules = stale_a.packages()[1].files()[0]
+    .edits()
+    .iter()
+    .map(|e| e.rule().as_str())
+    .collect::<Vec<_>>();
+  assert_eq!(rules, vec!["replace_call", "simplify_if_statement_true"]);
+}
+
+#[test]
+fn test_get_flag_cleanups_empty() {
+  assert!(get_flag_cleanups(vec![]).is_empty());
+}
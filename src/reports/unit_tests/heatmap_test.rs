@@ -0,0 +1,50 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use super::get_package_heatmap;
+
+#[test]
+fn test_get_package_heatmap() {
+  let file_usages = vec![
+    ("main.go".to_string(), 0, 1),
+    ("payments/api.go".to_string(), 1, 2),
+    ("payments/client.go".to_string(), 0, 3),
+    ("rides/dispatch/dispatch.go".to_string(), 2, 4),
+    ("rides/pricing.go".to_string(), 1, 0),
+    ("users/users.go".to_string(), 0, 1),
+  ];
+
+  let heatmap = get_package_heatmap(&file_usages);
+
+  let ranking = heatmap
+    .iter()
+    .map(|p| (p.package().as_str(), *p.files(), *p.effort()))
+    .collect::<Vec<_>>();
+  assert_eq!(
+    ranking,
+    vec![
+      ("payments", 2, 6),
+      ("rides/dispatch", 1, 6),
+      (".", 1, 1),
+      ("rides", 1, 1),
+      ("users", 1, 1),
+    ]
+  );
+  assert_eq!(*heatmap[0].matches(), 1);
+  assert_eq!(*heatmap[0].rewrites(), 5);
+}
+
+#[test]
+fn test_get_package_heatmap_empty() {
+  assert!(get_package_heatmap(&[]).is_empty());
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use super::{to_junit_xml, JUnitStatus, JUnitTestCase};
+use crate::utilities::eq_without_whitespace;
+
+#[test]
+fn test_to_junit_xml() {
+  let test_cases = vec![
+    JUnitTestCase::new("a/cleaned.go".to_string(), JUnitStatus::Passed),
+    JUnitTestCase::new(
+      "a/unused.go".to_string(),
+      JUnitStatus::Skipped("No usages found".to_string()),
+    ),
+    JUnitTestCase::new(
+      "a/large.go".to_string(),
+      JUnitStatus::Failed("Larger than the file size threshold (<100 bytes)".to_string()),
+    ),
+  ];
+
+  let expected = r#"<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="piranha" tests="3" failures="1" skipped="1">
+    <testcase classname="piranha" name="a/cleaned.go"/>
+    <testcase classname="piranha" name="a/unused.go">
+      <skipped message="No usages found"/>
+    </testcase>
+    <testcase classname="piranha" name="a/large.go">
+      <failure message="Larger than the file size threshold (&lt;100 bytes)"/>
+    </testcase>
+  </testsuite>
+</testsuites>"#;
+
+  assert!(eq_without_whitespace(
+    &to_junit_xml(&test_cases, None),
+    expected
+  ));
+}
+
+#[test]
+fn test_to_junit_xml_empty() {
+  let expected = r#"<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="piranha" tests="0" failures="0" skipped="0">
+  </testsuite>
+</testsuites>"#;
+
+  assert!(eq_without_whitespace(&to_junit_xml(&[], None), expected));
+}
@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use tempdir::TempDir;
+
+use crate::{
+  models::{
+    default_configs::GO, language::PiranhaLanguage, piranha_arguments::PiranhaArgumentsBuilder,
+  },
+  tests::substitutions,
+};
+
+use super::{get_head_commit, get_sha256, RunManifest, PIRANHA_VERSION};
+
+const COMMIT: &str = "0123456789abcdef0123456789abcdef01234567";
+
+#[test]
+fn test_head_commit_loose_ref() {
+  let temp_dir = TempDir::new("manifest").unwrap();
+  let git_dir = temp_dir.path().join(".git");
+  fs::create_dir_all(git_dir.join("refs").join("heads")).unwrap();
+  fs::write(git_dir.join("HEAD"), "ref: refs/heads/main\n").unwrap();
+  fs::write(
+    git_dir.join("refs").join("heads").join("main"),
+    format!("{COMMIT}\n"),
+  )
+  .unwrap();
+  let path_to_codebase = temp_dir.path().join("pkg");
+  fs::create_dir_all(&path_to_codebase).unwrap();
+
+  assert_eq!(
+    get_head_commit(path_to_codebase.to_str().unwrap()),
+    Some(COMMIT.to_string())
+  );
+}
+
+#[test]
+fn test_head_commit_packed_ref() {
+  let temp_dir = TempDir::new("manifest").unwrap();
+  let git_dir = temp_dir.path().join(".git");
+  fs::create_dir_all(&git_dir).unwrap();
+  fs::write(git_dir.join("HEAD"), "ref: refs/heads/main\n").unwrap();
+  let packed_refs = format!(
+    "# pack-refs with: peeled fully-peeled sorted\n{} refs/heads/mainline\n{COMMIT} refs/heads/main\n",
+    "f".repeat(40)
+  );
+  fs::write(git_dir.join("packed-refs"), packed_refs).unwrap();
+
+  assert_eq!(
+    get_head_commit(temp_dir.path().to_str().unwrap()),
+    Some(COMMIT.to_string())
+  );
+}
+
+#[test]
+fn test_head_commit_detached_worktree() {
+  let temp_dir = TempDir::new("manifest").unwrap();
+  let git_dir = temp_dir
+    .path()
+    .join("repo.git")
+    .join("worktrees")
+    .join("wt");
+  fs::create_dir_all(&git_dir).unwrap();
+  fs::write(git_dir.join("HEAD"), format!("{COMMIT}\n")).unwrap();
+  let worktree = temp_dir.path().join("wt");
+  fs::create_dir_all(&worktree).unwrap();
+  fs::write(
+    worktree.join(".git"),
+    format!("gitdir: {}\n", git_dir.display()),
+  )
+  .unwrap();
+
+  assert_eq!(
+    get_head_commit(worktree.to_str().unwrap()),
+    Some(COMMIT.to_string())
+  );
+}
+
+#[test]
+fn test_head_commit_outside_repository() {
+  let temp_dir = TempDir::new("manifest").unwrap();
+  // The temp dir may itself be within a git repository
+  if temp_dir.path().ancestors().any(|p| p.join(".git").exists()) {
+    return;
+  }
+  assert_eq!(get_head_commit(temp_dir.path().to_str().unwrap()), None);
+}
+
+#[test]
+fn test_run_manifest() {
+  let temp_dir = TempDir::new("manifest").unwrap();
+  let path_to_configurations = temp_dir.path().join("configurations");
+  fs::create_dir_all(&path_to_configurations).unwrap();
+  let manifest = |rules: &str, flag_name: &str, keep_flag_calls: bool| {
+    fs::write(path_to_configurations.join("rules.toml"), rules).unwrap();
+    let piranha_arguments = PiranhaArgumentsBuilder::default()
+      .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+      .path_to_configurations(path_to_configurations.to_str().unwrap().to_string())
+      .language(PiranhaLanguage::from(GO))
+      .substitutions(substitutions! {"flag_name" => flag_name})
+      .keep_flag_calls(keep_flag_calls)
+      .build();
+    RunManifest::new(&piranha_arguments)
+  };
+
+  let original = manifest("rules = []\n", "a", false);
+  assert_eq!(original.piranha_version(), PIRANHA_VERSION);
+  assert_eq!(original.language(), GO);
+  assert!(original
+    .rule_packs()
+    .iter()
+    .any(|pack| pack.name() == "rules.toml" && pack.hash().len() == 64));
+  assert!(original.config_hash().is_some());
+  assert_eq!(original.arguments().substitutions()["flag_name"], "a");
+
+  // The manifest only depends on the inputs of the run
+  assert_eq!(manifest("rules = []\n", "a", false), original);
+  let other_rules = manifest("rules = []\n\n", "a", false);
+  assert_ne!(other_rules.config_hash(), original.config_hash());
+  assert_eq!(other_rules.arguments_hash(), original.arguments_hash());
+  let other_flag = manifest("rules = []\n", "b", false);
+  assert_eq!(other_flag.config_hash(), original.config_hash());
+  assert_ne!(other_flag.arguments_hash(), original.arguments_hash());
+  // Any of the arguments affecting the cleanup (not only the substitutions)
+  let other_arguments = manifest("rules = []\n", "a", true);
+  assert!(*other_arguments.arguments().keep_flag_calls());
+  assert_ne!(other_arguments.arguments_hash(), original.arguments_hash());
+}
+
+#[test]
+fn test_sha256() {
+  assert_eq!(
+    get_sha256(""),
+    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+  );
+  assert_eq!(
+    get_sha256("abc"),
+    "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+  );
+}
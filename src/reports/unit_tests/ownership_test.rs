@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use tempdir::TempDir;
+
+use super::{
+  longest_path_prefix, owner_name, path_to_owner_patch, split_by_owner, write_patches_by_owner,
+  CodeOwners, UNOWNED,
+};
+use crate::reports::patch::FilePatch;
+
+const CODEOWNERS: &str = "
+# The default owners
+*                       @org/platform
+
+*.md                    @org/docs
+/services/payments/     @org/payments @alice
+services/ledger         @org/ledger
+/services/shared/*      @org/shared
+# Unowned
+/services/generated/
+";
+
+#[test]
+fn test_code_owners_of() {
+  let code_owners = CodeOwners::new(CODEOWNERS);
+  let owners_of = |path: &str| code_owners.owners_of(path).map(|owners| owners.join(" "));
+
+  assert_eq!(owners_of("main.go").as_deref(), Some("@org/platform"));
+  assert_eq!(
+    owners_of("services/payments/README.md").as_deref(),
+    Some("@org/payments @alice")
+  );
+  assert_eq!(
+    owners_of("services/payments/api/handler.go").as_deref(),
+    Some("@org/payments @alice")
+  );
+  assert_eq!(owners_of("docs/guide.md").as_deref(), Some("@org/docs"));
+  // The rules with a `/` are relative to the root
+  assert_eq!(
+    owners_of("services/ledger/ledger.go").as_deref(),
+    Some("@org/ledger")
+  );
+  assert_eq!(
+    owners_of("vendor/services/ledger/ledger.go").as_deref(),
+    Some("@org/platform")
+  );
+  // `/*` only matches the files directly in the directory
+  assert_eq!(
+    owners_of("services/shared/util.go").as_deref(),
+    Some("@org/shared")
+  );
+  assert_eq!(
+    owners_of("services/shared/sub/util.go").as_deref(),
+    Some("@org/platform")
+  );
+  // The last matching rule has no owners
+  assert_eq!(owners_of("services/generated/flags.go"), None);
+}
+
+#[test]
+fn test_longest_path_prefix() {
+  let prefixes = vec!["services".to_string(), "services/payments/".to_string()];
+  assert_eq!(
+    longest_path_prefix(&prefixes, "services/payments/a.go"),
+    Some(&"services/payments/".to_string())
+  );
+  assert_eq!(
+    longest_path_prefix(&prefixes, "services/paymentsv2/a.go"),
+    Some(&"services".to_string())
+  );
+  assert_eq!(longest_path_prefix(&prefixes, "main.go"), None);
+}
+
+#[test]
+fn test_owner_name() {
+  assert_eq!(owner_name("@org/payments @alice"), "org_payments+alice");
+  assert_eq!(owner_name("services/payments/"), "services_payments");
+  assert_eq!(owner_name("alice@example.com"), "alice_example.com");
+}
+
+#[test]
+fn test_path_to_owner_patch() {
+  assert_eq!(
+    path_to_owner_patch("out/edits.patch", "org_payments"),
+    "out/edits.org_payments.patch"
+  );
+  assert_eq!(path_to_owner_patch("edits", UNOWNED), "edits.unowned");
+}
+
+#[test]
+fn test_write_patches_by_owner() {
+  let file_patch = |path: &str| {
+    FilePatch::new(
+      path.to_string(),
+      "before\n".to_string(),
+      Some("after\n".to_string()),
+    )
+  };
+  let file_patches = vec![
+    file_patch("services/payments/a.go"),
+    file_patch("services/ledger/b.go"),
+    file_patch("services/payments/c.go"),
+  ];
+  let prefixes = vec!["services/payments".to_string()];
+  let file_patches_by_owner = split_by_owner(&file_patches, |path| {
+    longest_path_prefix(&prefixes, path).cloned()
+  });
+
+  let temp_dir = TempDir::new("patches").unwrap();
+  let path_to_patch = temp_dir.path().join("edits.patch");
+  write_patches_by_owner(&file_patches_by_owner, path_to_patch.to_str().unwrap());
+
+  let payments_patch =
+    fs::read_to_string(temp_dir.path().join("edits.services_payments.patch")).unwrap();
+  assert!(payments_patch.contains("--- a/services/payments/a.go\n"));
+  assert!(payments_patch.contains("--- a/services/payments/c.go\n"));
+  assert!(!payments_patch.contains("ledger"));
+
+  let unowned_patch = fs::read_to_string(temp_dir.path().join("edits.unowned.patch")).unwrap();
+  assert!(unowned_patch.contains("--- a/services/ledger/b.go\n"));
+  assert!(!unowned_patch.contains("payments"));
+
+  // The patch is not written as a whole
+  assert!(!path_to_patch.exists());
+}
@@ -0,0 +1,151 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use super::{to_patch, FilePatch};
+
+fn lines(range: std::ops::RangeInclusive<usize>) -> String {
+  range.map(|i| format!("line {i}\n")).collect()
+}
+
+#[test]
+fn test_to_unified_diff() {
+  let original = lines(1..=10);
+  let updated = original
+    .replace("line 5\n", "")
+    .replace("line 6\n", "line six\n");
+  let file_patch = FilePatch::new("a/lines.go".to_string(), original, Some(updated));
+
+  let expected = "diff --git a/a/lines.go b/a/lines.go
+--- a/a/lines.go
++++ b/a/lines.go
+@@ -2,8 +2,7 @@
+ line 2
+ line 3
+ line 4
+-line 5
+-line 6
++line six
+ line 7
+ line 8
+ line 9
+";
+  assert_eq!(file_patch.to_unified_diff(), expected);
+}
+
+#[test]
+fn test_to_unified_diff_several_hunks() {
+  let original = lines(1..=20);
+  let updated = original
+    .replace("line 2\n", "line two\n")
+    .replace("line 9\n", "line nine\n")
+    .replace("line 18\n", "");
+  let file_patch = FilePatch::new("lines.go".to_string(), original, Some(updated));
+
+  // The changes of `line 2` and `line 9` are separated by 6 unchanged lines, i.e. twice the context
+  let expected = "diff --git a/lines.go b/lines.go
+--- a/lines.go
++++ b/lines.go
+@@ -1,12 +1,12 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+ line 5
+ line 6
+ line 7
+ line 8
+-line 9
++line nine
+ line 10
+ line 11
+ line 12
+@@ -15,6 +15,5 @@
+ line 15
+ line 16
+ line 17
+-line 18
+ line 19
+ line 20
+";
+  assert_eq!(file_patch.to_unified_diff(), expected);
+}
+
+#[test]
+fn test_get_hunks() {
+  let original = lines(1..=20);
+  let updated = original
+    .replace("line 2\n", "line two\n")
+    .replace("line 9\n", "line nine\n")
+    .replace("line 18\n", "");
+  let file_patch = FilePatch::new("lines.go".to_string(), original, Some(updated));
+
+  // As in the unified diff, but without the unchanged lines around the changes
+  let hunks = file_patch.get_hunks();
+  assert_eq!(hunks.len(), 2);
+  assert_eq!(
+    (hunks[0].old_lines(), hunks[0].new_lines()),
+    (&(1..9), &(1..9))
+  );
+  assert_eq!(
+    (hunks[1].old_lines(), hunks[1].new_lines()),
+    (&(17..18), &(17..17))
+  );
+}
+
+#[test]
+fn test_to_unified_diff_unchanged() {
+  let file_patch = FilePatch::new("lines.go".to_string(), lines(1..=3), Some(lines(1..=3)));
+  assert_eq!(file_patch.to_unified_diff(), "");
+}
+
+#[test]
+fn test_to_unified_diff_no_newline_at_end_of_file() {
+  let file_patch = FilePatch::new(
+    "lines.go".to_string(),
+    "line 1\nline 2".to_string(),
+    Some("line 1\n".to_string()),
+  );
+
+  let expected = "diff --git a/lines.go b/lines.go
+--- a/lines.go
++++ b/lines.go
+@@ -1,2 +1,1 @@
+ line 1
+-line 2
+\\ No newline at end of file
+";
+  assert_eq!(file_patch.to_unified_diff(), expected);
+}
+
+#[test]
+fn test_to_patch_deleted_file() {
+  let file_patches = vec![
+    FilePatch::new("b.go".to_string(), lines(1..=2), None),
+    FilePatch::new("a.go".to_string(), lines(1..=1), Some(String::new())),
+  ];
+
+  let expected = "diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,1 +0,0 @@
+-line 1
+diff --git a/b.go b/b.go
+--- a/b.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-line 1
+-line 2
+";
+  assert_eq!(to_patch(&file_patches), expected);
+}
@@ -0,0 +1,100 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use tempdir::TempDir;
+
+use super::EditPlan;
+use crate::reports::patch::FilePatch;
+
+fn get_file_patches() -> Vec<FilePatch> {
+  vec![
+    FilePatch::new(
+      "pkg/a.go".to_string(),
+      "before\n".to_string(),
+      Some("after\n".to_string()),
+    ),
+    FilePatch::new(
+      "b.go".to_string(),
+      "same\n".to_string(),
+      Some("same\n".to_string()),
+    ),
+    FilePatch::new("c.go".to_string(), "deleted\n".to_string(), None),
+  ]
+}
+
+fn write_code_base(code_base: &TempDir) {
+  fs::create_dir_all(code_base.path().join("pkg")).unwrap();
+  fs::write(code_base.path().join("pkg/a.go"), "before\n").unwrap();
+  fs::write(code_base.path().join("b.go"), "same\n").unwrap();
+  fs::write(code_base.path().join("c.go"), "deleted\n").unwrap();
+}
+
+#[test]
+fn test_new_omits_unchanged_files() {
+  let plan = EditPlan::new("code_base".to_string(), &get_file_patches());
+  let paths = plan
+    .files()
+    .iter()
+    .map(|f| f.path().as_str())
+    .collect::<Vec<_>>();
+  assert_eq!(paths, vec!["c.go", "pkg/a.go"]);
+}
+
+#[test]
+fn test_write_and_read() {
+  let plan = EditPlan::new("code_base".to_string(), &get_file_patches());
+  let temp_dir = TempDir::new("plan").unwrap();
+  let path_to_plan = temp_dir.path().join("plan.json");
+  let path_to_plan = path_to_plan.to_str().unwrap();
+
+  plan.write(path_to_plan);
+  assert_eq!(EditPlan::read(path_to_plan), plan);
+}
+
+#[test]
+fn test_apply() {
+  let code_base = TempDir::new("code_base").unwrap();
+  write_code_base(&code_base);
+  let plan = EditPlan::new(
+    code_base.path().to_str().unwrap().to_string(),
+    &get_file_patches(),
+  );
+
+  assert!(plan.get_conflicts().is_empty());
+  plan.apply();
+  assert_eq!(
+    fs::read_to_string(code_base.path().join("pkg/a.go")).unwrap(),
+    "after\n"
+  );
+  assert_eq!(
+    fs::read_to_string(code_base.path().join("b.go")).unwrap(),
+    "same\n"
+  );
+  assert!(!code_base.path().join("c.go").exists());
+}
+
+#[test]
+fn test_get_conflicts() {
+  let code_base = TempDir::new("code_base").unwrap();
+  write_code_base(&code_base);
+  // Edited after the plan was made
+  fs::write(code_base.path().join("pkg/a.go"), "edited\n").unwrap();
+  let plan = EditPlan::new(
+    code_base.path().to_str().unwrap().to_string(),
+    &get_file_patches(),
+  );
+
+  assert_eq!(plan.get_conflicts(), vec!["pkg/a.go".to_string()]);
+}
@@ -0,0 +1,144 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use std::fs;
+
+use tempdir::TempDir;
+
+use super::QuickFixBundle;
+use crate::reports::{manifest::get_sha256, patch::FilePatch};
+
+fn get_file_patches() -> Vec<FilePatch> {
+  vec![
+    FilePatch::new(
+      "pkg/a.go".to_string(),
+      "func a() {\n  if enabled {\n    newFlow()\n  }\n}\n".to_string(),
+      Some("func a() {\n  newFlow()\n}\n".to_string()),
+    ),
+    FilePatch::new(
+      "b.go".to_string(),
+      "same\n".to_string(),
+      Some("same\n".to_string()),
+    ),
+    FilePatch::new("c.go".to_string(), "deleted\n".to_string(), None),
+  ]
+}
+
+fn write_code_base(code_base: &TempDir) {
+  fs::create_dir_all(code_base.path().join("pkg")).unwrap();
+  for patch in get_file_patches() {
+    fs::write(code_base.path().join(patch.path()), patch.original()).unwrap();
+  }
+}
+
+#[test]
+fn test_new_computes_the_changed_ranges() {
+  let bundle = QuickFixBundle::new("code_base".to_string(), &get_file_patches());
+  let paths = bundle
+    .fixes()
+    .iter()
+    .map(|f| f.path().as_str())
+    .collect::<Vec<_>>();
+  assert_eq!(paths, vec!["c.go", "pkg/a.go"]);
+
+  let deleted = &bundle.fixes()[0];
+  assert!(*deleted.deleted());
+  assert!(deleted.edits().is_empty());
+
+  let fix = &bundle.fixes()[1];
+  assert_eq!(
+    fix.checksum(),
+    &get_sha256("func a() {\n  if enabled {\n    newFlow()\n  }\n}\n")
+  );
+  assert_eq!(fix.edits().len(), 1);
+  let edit = &fix.edits()[0];
+  assert_eq!((*edit.start_byte(), *edit.end_byte()), (11, 44));
+  assert_eq!((*edit.start_row(), *edit.start_column()), (1, 0));
+  assert_eq!((*edit.end_row(), *edit.end_column()), (4, 0));
+  assert_eq!(edit.replacement(), "  newFlow()\n");
+}
+
+#[test]
+fn test_new_one_edit_per_hunk() {
+  let original = (1..=20).map(|i| format!("line {i}\n")).collect::<String>();
+  let updated = original
+    .replace("line 2\n", "line two\n")
+    .replace("line 18\n", "");
+  let bundle = QuickFixBundle::new(
+    "code_base".to_string(),
+    &[FilePatch::new(
+      "lines.go".to_string(),
+      original,
+      Some(updated),
+    )],
+  );
+
+  let edits = bundle.fixes()[0].edits();
+  assert_eq!(edits.len(), 2);
+  assert_eq!((*edits[0].start_row(), *edits[0].end_row()), (1, 2));
+  assert_eq!(edits[0].replacement(), "line two\n");
+  assert_eq!((*edits[1].start_row(), *edits[1].end_row()), (17, 18));
+  assert_eq!(edits[1].replacement(), "");
+}
+
+#[test]
+fn test_write_and_read() {
+  let bundle = QuickFixBundle::new("code_base".to_string(), &get_file_patches());
+  let temp_dir = TempDir::new("quick_fix").unwrap();
+  let path_to_bundle = temp_dir.path().join("quick_fixes.json");
+  let path_to_bundle = path_to_bundle.to_str().unwrap();
+
+  bundle.write(path_to_bundle);
+  assert_eq!(QuickFixBundle::read(path_to_bundle), bundle);
+}
+
+#[test]
+fn test_apply() {
+  let code_base = TempDir::new("code_base").unwrap();
+  write_code_base(&code_base);
+  let bundle = QuickFixBundle::new(
+    code_base.path().to_str().unwrap().to_string(),
+    &get_file_patches(),
+  );
+
+  assert!(bundle.get_conflicts().is_empty());
+  bundle.apply();
+  assert_eq!(
+    fs::read_to_string(code_base.path().join("pkg/a.go")).unwrap(),
+    "func a() {\n  newFlow()\n}\n"
+  );
+  assert_eq!(
+    fs::read_to_string(code_base.path().join("b.go")).unwrap(),
+    "same\n"
+  );
+  assert!(!code_base.path().join("c.go").exists());
+}
+
+#[test]
+fn test_get_conflicts() {
+  let code_base = TempDir::new("code_base").unwrap();
+  write_code_base(&code_base);
+  // Edited after the analysis
+  fs::write(code_base.path().join("pkg/a.go"), "edited\n").unwrap();
+  // Already deleted
+  fs::remove_file(code_base.path().join("c.go")).unwrap();
+  let bundle = QuickFixBundle::new(
+    code_base.path().to_str().unwrap().to_string(),
+    &get_file_patches(),
+  );
+
+  assert_eq!(
+    bundle.get_conflicts(),
+    vec!["c.go".to_string(), "pkg/a.go".to_string()]
+  );
+}
@@ -0,0 +1,116 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use super::{RenamedIdentifier, RunReport};
+use crate::utilities::eq_without_whitespace;
+
+#[test]
+fn test_partial_run_report() {
+  let run_report = RunReport::partial(
+    "Could not parse code".to_string(),
+    "b.go".to_string(),
+    vec!["a.go".to_string()],
+    vec!["b.go".to_string(), "c.go".to_string()],
+  );
+
+  let expected = r#"{
+    "status": "partial",
+    "error": "Could not parse code",
+    "failed_file": "b.go",
+    "updated_files": ["a.go"],
+    "remaining_files": ["b.go", "c.go"]
+  }"#;
+
+  assert!(run_report.is_partial());
+  assert!(eq_without_whitespace(
+    &serde_json::to_string(&run_report).unwrap(),
+    expected
+  ));
+}
+
+#[test]
+fn test_complete_run_report() {
+  let run_report = RunReport::complete(vec!["a.go".to_string()]);
+
+  let expected = r#"{
+    "status": "complete",
+    "error": null,
+    "failed_file": null,
+    "updated_files": ["a.go"],
+    "remaining_files": []
+  }"#;
+
+  assert!(!run_report.is_partial());
+  assert!(eq_without_whitespace(
+    &serde_json::to_string(&run_report).unwrap(),
+    expected
+  ));
+}
+
+#[test]
+fn test_checkpointed_run_report() {
+  let run_report = RunReport::checkpointed(
+    vec!["a.go".to_string()],
+    vec!["b.go".to_string(), "c.go".to_string()],
+  );
+
+  let expected = r#"{
+    "status": "checkpointed",
+    "error": null,
+    "failed_file": null,
+    "updated_files": ["a.go"],
+    "remaining_files": ["b.go", "c.go"]
+  }"#;
+
+  assert!(!run_report.is_partial());
+  assert!(run_report.is_checkpointed());
+  assert!(eq_without_whitespace(
+    &serde_json::to_string(&run_report).unwrap(),
+    expected
+  ));
+}
+
+#[test]
+fn test_run_report_renamed_identifiers() {
+  let run_report = RunReport::complete(vec!["a.go".to_string(), "b.go".to_string()])
+    .with_renamed_identifiers(vec![
+      RenamedIdentifier::new(
+        "newCheckoutFlow".to_string(),
+        "checkoutFlow".to_string(),
+        3,
+        vec!["a.go".to_string(), "b.go".to_string()],
+      ),
+      RenamedIdentifier::refused(
+        "handlerV2".to_string(),
+        "handler".to_string(),
+        "`handler` is already used by b.go".to_string(),
+      ),
+    ]);
+
+  let expected = r#"{
+    "status": "complete",
+    "error": null,
+    "failed_file": null,
+    "updated_files": ["a.go", "b.go"],
+    "remaining_files": [],
+    "renamed_identifiers": [
+      {"from": "newCheckoutFlow", "to": "checkoutFlow", "occurrences": 3, "files": ["a.go", "b.go"]},
+      {"from": "handlerV2", "to": "handler", "occurrences": 0, "files": [], "refusal": "`handler` is already used by b.go"}
+    ]
+  }"#;
+
+  assert!(eq_without_whitespace(
+    &serde_json::to_string(&run_report).unwrap(),
+    expected
+  ));
+}
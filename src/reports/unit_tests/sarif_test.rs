@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+use tree_sitter::{Point, Range};
+
+use super::{to_sarif, SarifResult};
+
+fn range(start: (usize, usize), end: (usize, usize)) -> Range {
+  Range {
+    start_byte: 0,
+    end_byte: 0,
+    start_point: Point::new(start.0, start.1),
+    end_point: Point::new(end.0, end.1),
+  }
+}
+
+#[test]
+fn test_to_sarif() {
+  let results = vec![
+    SarifResult::new(
+      "lint_concatenated_flag_name".to_string(),
+      "rides/pricing.go".to_string(),
+      "exp.BoolValue(\"new_flow_\" + region)".to_string(),
+      range((11, 11), (11, 46)),
+    ),
+    SarifResult::new(
+      "find_flag_usage".to_string(),
+      "main.go".to_string(),
+      "exp.BoolValue(\"new_flow\")".to_string(),
+      range((3, 4), (3, 29)),
+    ),
+  ];
+
+  let expected = r#"{
+    "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+    "version": "2.1.0",
+    "runs": [{
+      "tool": {
+        "driver": {
+          "name": "piranha",
+          "informationUri": "https://github.com/uber/piranha",
+          "rules": [{"id": "find_flag_usage"}, {"id": "lint_concatenated_flag_name"}]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "lint_concatenated_flag_name",
+          "level": "warning",
+          "message": {"text": "`exp.BoolValue(\"new_flow_\" + region)` matches the rule `lint_concatenated_flag_name`"},
+          "locations": [{
+            "physicalLocation": {
+              "artifactLocation": {"uri": "rides/pricing.go"},
+              "region": {"startLine": 12, "startColumn": 12, "endLine": 12, "endColumn": 47}
+            }
+          }]
+        },
+        {
+          "ruleId": "find_flag_usage",
+          "level": "warning",
+          "message": {"text": "`exp.BoolValue(\"new_flow\")` matches the rule `find_flag_usage`"},
+          "locations": [{
+            "physicalLocation": {
+              "artifactLocation": {"uri": "main.go"},
+              "region": {"startLine": 4, "startColumn": 5, "endLine": 4, "endColumn": 30}
+            }
+          }]
+        }
+      ]
+    }]
+  }"#;
+
+  assert_eq!(
+    to_sarif(&results),
+    serde_json::from_str::<serde_json::Value>(expected).unwrap()
+  );
+}
+
+#[test]
+fn test_to_sarif_no_results() {
+  let sarif = to_sarif(&[]);
+  assert_eq!(sarif["runs"][0]["results"], serde_json::json!([]));
+  assert_eq!(
+    sarif["runs"][0]["tool"]["driver"]["rules"],
+    serde_json::json!([])
+  );
+}
@@ -11,16 +11,66 @@ Copyright (c) 2023 Uber Technologies, Inc.
  limitations under the License.
 */
 
-use std::collections::HashMap;
+use std::{
+  collections::HashMap,
+  fs,
+  panic::{self, AssertUnwindSafe},
+  path::{Path, PathBuf},
+  time::{Duration, Instant},
+};
 
-use super::{create_match_tests, create_rewrite_tests, substitutions};
+use glob::Pattern;
+use tempdir::TempDir;
 
-use crate::models::default_configs::GO;
+use super::{
+  copy_folder_to_temp_dir, create_match_tests, create_rewrite_tests, initialize, substitutions,
+};
+
+use crate::{
+  apply_plan, apply_quick_fix_bundle, check_piranha, execute_piranha, get_dry_run_diff,
+  get_flag_remnants, get_removed_flag_usages,
+  models::{
+    default_configs::GO, language::PiranhaLanguage, piranha_arguments::PiranhaArgumentsBuilder,
+  },
+  plan_piranha,
+  utilities::eq_without_whitespace,
+  Piranha,
+};
 
 create_match_tests! {
   GO,
   test_match_only_for_loop: "structural_find/go_stmt_for_loop", HashMap::from([("find_go_stmt_for_loop", 1)]);
   test_match_only_go_stmt_for_loop:"structural_find/for_loop", HashMap::from([("find_for", 4)]);
+  test_disable_file_detection: "feature_flag/disable_file",
+    HashMap::from([("true_flag", 2), ("false_flag", 1)]),
+    substitutions = substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    dry_run = true;
+  test_lint_uncleanable_flag_usage: "structural_find/uncleanable_flag_usage",
+    HashMap::from([
+      ("find_flag_usage", 5),
+      ("lint_concatenated_flag_name", 1),
+      ("lint_formatted_flag_name", 1),
+      ("lint_reflection_flag_lookup", 1),
+      ("lint_flag_name_from_map", 1),
+      ("lint_flag_name_from_config", 1)
+    ]),
+    substitutions = substitutions! {
+      "flag_api" => "BoolValue"
+    },
+    lint_uncleanable_patterns = true;
+  test_removed_flag_usage: "structural_find/removed_flag_usage",
+    HashMap::from([
+      ("find_flag_usage", 4),
+      ("removed_flag_api_call", 3),
+      ("removed_flag_constant", 1)
+    ]),
+    substitutions = substitutions! {
+      "flag_api" => "BoolValue"
+    },
+    removed_flags = vec!["stale_flag".to_string(), "payments.stale".to_string()];
 }
 
 create_rewrite_tests! {
@@ -36,9 +86,972 @@ create_rewrite_tests! {
       "treated" => "true",
       "treated_complement" => "false"
     }, cleanup_comments = true;
+  test_builtin_guard_clause_cleanup: "feature_flag/builtin_rules/guard_clause_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_provenance_comments: "feature_flag/builtin_rules/provenance_comments", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    provenance_comment = Some("staleFlag".to_string());
+  test_strip_provenance_comments: "feature_flag/builtin_rules/strip_provenance_comments", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    strip_provenance_comments = true;
+  test_builtin_switch_init_cleanup: "feature_flag/builtin_rules/switch_init_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_package_variable_cleanup: "feature_flag/builtin_rules/package_variable_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, cleanup_comments = true;
+  test_builtin_package_variable_cleanup_multi_file: "feature_flag/builtin_rules/package_variable_cleanup_multi_file", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_else_branch_cleanup: "feature_flag/builtin_rules/else_branch_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_short_circuit_assignment: "feature_flag/builtin_rules/short_circuit_assignment", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_short_circuit_chain: "feature_flag/builtin_rules/short_circuit_chain", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_embed_cleanup: "feature_flag/builtin_rules/embed_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_config_values: "feature_flag/builtin_rules/config_values", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    path_to_config_values = Some("test-resources/go/feature_flag/builtin_rules/config_values/config_values.toml".to_string());
+  test_builtin_renames: "feature_flag/builtin_rules/renames", 2,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    renames = vec![
+      "newCheckoutFlow=checkoutFlow".to_string(),
+      "handlerV2=handler".to_string(),
+      "ServeV2=Serve".to_string(),
+      "legacyRegion=region".to_string()
+    ];
+  test_builtin_closure_cleanup: "feature_flag/builtin_rules/closure_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_select_statement_cleanup: "feature_flag/builtin_rules/select_statement_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_stale_flag_collection_cleanup: "feature_flag/builtin_rules/stale_flag_collection_cleanup", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag"
+    };
+  test_builtin_stale_flag_map_cleanup: "feature_flag/builtin_rules/stale_flag_map_cleanup", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag"
+    };
+  test_builtin_recover_fallback_cleanup: "feature_flag/builtin_rules/recover_fallback_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_panics_assertion_cleanup: "feature_flag/builtin_rules/panics_assertion_cleanup", 2,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_string_literal_cleanup: "feature_flag/builtin_rules/string_literal_cleanup", 1,
+    substitutions= substitutions! {
+      "string_flag" => "greeting",
+      "string_value" => "hello",
+      "empty_string_flag" => "farewell"
+    };
+  test_builtin_return_tuple_cleanup: "feature_flag/builtin_rules/return_tuple_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_error_result_assume_nil: "feature_flag/builtin_rules/error_result_cleanup/assume_nil", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_builtin_error_result_preserve_call: "feature_flag/builtin_rules/error_result_cleanup/preserve_call", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, error_result_handling = "preserve_call".to_string();
+  test_builtin_error_result_keep_handling: "feature_flag/builtin_rules/error_result_cleanup/keep_handling", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, error_result_handling = "keep_handling".to_string();
+  test_builtin_ok_result_assume_ok: "feature_flag/builtin_rules/ok_result_cleanup/assume_ok", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, ok_result_handling = Some("assume_ok".to_string());
+  test_builtin_ok_result_preserve_check: "feature_flag/builtin_rules/ok_result_cleanup/preserve_check", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, ok_result_handling = Some("preserve_check".to_string());
+  test_builtin_keep_flag_calls: "feature_flag/builtin_rules/keep_flag_calls/keep_call", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, keep_flag_calls = true;
+  test_builtin_keep_flag_calls_with_replacement: "feature_flag/builtin_rules/keep_flag_calls/no_op_call", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, keep_flag_calls = true,
+    flag_call_replacement = Some("exp.RecordExposure(@arg_str_literal)".to_string());
+  test_builtin_deleted_branch_replacement: "feature_flag/builtin_rules/deleted_branch_replacement", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    },
+    deleted_branch_replacement = Some("metrics.Inc(\"piranha.fallback\", @arg_str_literal)".to_string());
+  test_large_file: "feature_flag/large_file", 0,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, file_size_threshold = 100;
+  test_deeply_nested_file: "feature_flag/deeply_nested_file", 0,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, max_nesting_depth = 20;
+  test_disable_file: "feature_flag/disable_file", 2,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    };
+  test_large_file_forced: "feature_flag/builtin_rules/statement_cleanup", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, cleanup_comments = true, file_size_threshold = 100, force_large_files = true;
+  test_disabled_builtin_rules: "feature_flag/builtin_rules/disabled_builtin_rules", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, disabled_builtin_rules = vec!["boolean_expression_simplify".to_string(), "delete_statement_after_return".to_string()];
+  test_comment_out_deletions: "feature_flag/builtin_rules/comment_out_deletions", 1,
+    substitutions= substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    }, comment_out_deletions = vec!["delete_statement_after_return".to_string()];
   test_const_same_file: "feature_flag/system_1/const_same_file", 1,
     substitutions= substitutions! {
       "stale_flag_name" => "staleFlag",
       "treated" => "false"
     };
+  test_unsupported_syntax_const: "feature_flag/unsupported_syntax_const", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "stale_flag",
+      "treated" => "false"
+    }, unsupported_syntax = vec!["[?][?]".to_string()];
+  test_receiver_methods: "feature_flag/system_1/receiver_methods", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag",
+      "treated" => "false"
+    };
+  test_method_values: "feature_flag/system_1/method_values", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag",
+      "treated" => "true"
+    };
+  test_const_alias: "feature_flag/system_1/const_alias", 3,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag",
+      "treated" => "false"
+    };
+  test_const_prefix: "feature_flag/system_1/const_prefix", 2,
+    substitutions= substitutions! {
+      "stale_flag_name" => "payments.staleFlag",
+      "treated" => "false"
+    };
+  test_generic_helper: "feature_flag/system_1/generic_helper", 1,
+    substitutions= substitutions! {
+      "stale_flag_name" => "staleFlag",
+      "flag_helper" => "GetFlag",
+      "flag_type" => "bool",
+      "treated" => "true"
+    };
+}
+
+/// This test checks that when an internal error interrupts the cleanup (of `b.go`), the edits
+/// already computed (for `a.go`) are written to the patch, the run is reported as partial and
+/// no file is edited in place.
+#[test]
+fn test_partial_run() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("partial_run");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let path_to_patch = temp_dir.path().join("edits.patch");
+  let path_to_run_report = temp_dir.path().join("run_report.json");
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .path_to_patch(Some(path_to_patch.to_str().unwrap().to_string()))
+    .path_to_run_report(Some(path_to_run_report.to_str().unwrap().to_string()))
+    .build();
+
+  let result = panic::catch_unwind(AssertUnwindSafe(|| execute_piranha(&piranha_arguments)));
+  assert!(result.is_err());
+
+  let patch = fs::read_to_string(&path_to_patch).unwrap();
+  assert!(patch.contains("--- a/a.go\n+++ b/a.go\n"));
+  assert!(patch.contains("-\tenabled := exp.BoolValue(\"true\")\n+\tenabled := true\n"));
+  assert!(!patch.contains("b.go"));
+
+  let run_report: serde_json::Value =
+    serde_json::from_str(&fs::read_to_string(&path_to_run_report).unwrap()).unwrap();
+  assert_eq!(run_report["status"], "partial");
+  assert_eq!(run_report["failed_file"], "b.go");
+  assert_eq!(run_report["updated_files"], serde_json::json!(["a.go"]));
+  assert_eq!(run_report["remaining_files"], serde_json::json!(["b.go"]));
+
+  for file_name in ["a.go", "b.go"] {
+    assert_eq!(
+      fs::read_to_string(temp_dir.path().join(file_name)).unwrap(),
+      fs::read_to_string(_path.join("input").join(file_name)).unwrap()
+    );
+  }
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that the edits of a file producing a syntax error (`b.go`) are rolled back,
+/// while the other files (`a.go`) are still cleaned up and written to disk.
+#[test]
+fn test_syntax_error_rollback() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("syntax_error_rollback");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .build();
+
+  let result = panic::catch_unwind(AssertUnwindSafe(|| execute_piranha(&piranha_arguments)));
+  let error = result.unwrap_err();
+  let message = error.downcast_ref::<String>().unwrap();
+  assert!(message.contains("b.go"));
+  assert!(!message.contains("a.go"));
+
+  for file_name in ["a.go", "b.go"] {
+    assert!(eq_without_whitespace(
+      &fs::read_to_string(temp_dir.path().join(file_name)).unwrap(),
+      &fs::read_to_string(_path.join("expected").join(file_name)).unwrap()
+    ));
+  }
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that a rename applies to the package declaring the old name, and to the qualified references (or the
+/// exported methods) of the packages importing it, while the local variables named like the old name are left untouched.
+#[test]
+fn test_renames_across_packages() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("renames_across_packages");
+  // The code base spans several packages (i.e. directories)
+  let file_names = ["main.go", "checkout/flow.go", "legacy/legacy.go"];
+  let temp_dir = TempDir::new_in(".", "tmp_test").unwrap();
+  for file_name in file_names {
+    let path = temp_dir.path().join(file_name);
+    fs::create_dir_all(path.parent().unwrap()).unwrap();
+    fs::copy(_path.join("input").join(file_name), path).unwrap();
+  }
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .renames(vec![
+      "NewCheckoutFlow=CheckoutFlow".to_string(),
+      "ServeV2=Serve".to_string(),
+    ])
+    .build();
+
+  let output_summaries = execute_piranha(&piranha_arguments);
+  assert_eq!(output_summaries.len(), 3);
+  for file_name in file_names {
+    assert!(eq_without_whitespace(
+      &fs::read_to_string(temp_dir.path().join(file_name)).unwrap(),
+      &fs::read_to_string(_path.join("expected").join(file_name)).unwrap()
+    ));
+  }
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that the reads of a package level nested struct field are replaced in every file of its package,
+/// but neither in the other packages nor when the variable is shadowed, addressed or passed to a function.
+#[test]
+fn test_builtin_nested_struct_literal_cleanup() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("nested_struct_literal_cleanup");
+  // The code base spans several packages (i.e. directories)
+  let file_names = ["sample.go", "other.go", "other/other.go"];
+  let temp_dir = TempDir::new_in(".", "tmp_test").unwrap();
+  for file_name in file_names {
+    let path = temp_dir.path().join(file_name);
+    fs::create_dir_all(path.parent().unwrap()).unwrap();
+    fs::copy(_path.join("input").join(file_name), path).unwrap();
+  }
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .build();
+
+  let output_summaries = execute_piranha(&piranha_arguments);
+  assert_eq!(output_summaries.len(), 2);
+  for file_name in file_names {
+    assert!(eq_without_whitespace(
+      &fs::read_to_string(temp_dir.path().join(file_name)).unwrap(),
+      &fs::read_to_string(_path.join("expected").join(file_name)).unwrap()
+    ));
+  }
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that a run reaching its deadline finishes the file in flight (`a.go`) and checkpoints
+/// the remaining files, and that a later run resumes from the checkpoint (i.e. only processes `b.go`).
+#[test]
+fn test_deadline_checkpoint() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("deadline_checkpoint");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let checkpoint_dir = TempDir::new("checkpoint").unwrap();
+  let path_to_checkpoint = checkpoint_dir.path().join("checkpoint.json");
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .deadline(60)
+    .path_to_checkpoint(Some(path_to_checkpoint.to_str().unwrap().to_string()))
+    .build();
+
+  // Simulate a run that already used its time budget
+  let mut piranha = Piranha::new(&piranha_arguments);
+  piranha.started = Instant::now() - Duration::from_secs(120);
+  piranha.perform_cleanup();
+
+  let run_report = piranha.get_run_report();
+  assert!(run_report.is_checkpointed());
+  assert_eq!(run_report.updated_files(), &vec!["a.go".to_string()]);
+  assert_eq!(run_report.remaining_files(), &vec!["b.go".to_string()]);
+  piranha
+    .checkpoint
+    .as_ref()
+    .unwrap()
+    .write(path_to_checkpoint.to_str().unwrap());
+
+  // Resume from the checkpoint
+  execute_piranha(&piranha_arguments);
+
+  let read = |name: &str| fs::read_to_string(temp_dir.path().join(name)).unwrap();
+  assert_eq!(
+    read("a.go"),
+    fs::read_to_string(_path.join("input").join("a.go")).unwrap()
+  );
+  assert!(!read("b.go").contains("exp.BoolValue"));
+  assert!(!read("b.go").contains("old refund"));
+  // The checkpoint is deleted once the resumed run completes
+  assert!(!path_to_checkpoint.exists());
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that a strict run refuses to edit the code base when a usage cannot be cleaned up
+/// (here, a flag name built at runtime), and lists the blockers.
+#[test]
+fn test_strict_mode() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("strict_mode");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false",
+      "flag_api" => "BoolValue"
+    })
+    .strict(true)
+    .build();
+
+  let result = panic::catch_unwind(AssertUnwindSafe(|| execute_piranha(&piranha_arguments)));
+  let error = result.unwrap_err();
+  let message = error.downcast_ref::<String>().unwrap();
+  assert!(
+    message.contains("a.go:28:8: uncleanable usage (lint_concatenated_flag_name)"),
+    "{message}"
+  );
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("a.go")).unwrap(),
+    fs::read_to_string(_path.join("input").join("a.go")).unwrap()
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that a function using a syntax the grammar does not support (here, a hypothetical `??` operator)
+/// is skipped, while the rest of its file is cleaned up, and that the run report lists the skipped function.
+#[test]
+fn test_unsupported_syntax() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("unsupported_syntax");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let path_to_run_report = temp_dir.path().join("run_report.json");
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .path_to_run_report(Some(path_to_run_report.to_str().unwrap().to_string()))
+    .unsupported_syntax(vec!["[?][?]".to_string()])
+    .build();
+
+  let summaries = execute_piranha(&piranha_arguments);
+  assert_eq!(summaries.len(), 1);
+  assert!(eq_without_whitespace(
+    &fs::read_to_string(temp_dir.path().join("a.go")).unwrap(),
+    &fs::read_to_string(_path.join("expected").join("a.go")).unwrap()
+  ));
+
+  let run_report: serde_json::Value =
+    serde_json::from_str(&fs::read_to_string(&path_to_run_report).unwrap()).unwrap();
+  assert_eq!(run_report["status"], "complete");
+  let skipped_function = &run_report["skipped_functions"][0];
+  assert_eq!(skipped_function["path"], "a.go");
+  assert_eq!(skipped_function["function"], "withDefault");
+  assert_eq!(skipped_function["line"], 18);
+  assert!(skipped_function["reason"]
+    .as_str()
+    .unwrap()
+    .contains(" at 20:"));
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that the flag report groups the edits of a multi-flag run (here, the flags `true` and `false`)
+/// by the flag captured by the seed match of their cascade.
+#[test]
+fn test_flag_report() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("short_circuit_assignment");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let path_to_flag_report = temp_dir.path().join("flag_report.json");
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .path_to_flag_report(Some(path_to_flag_report.to_str().unwrap().to_string()))
+    .build();
+
+  let summaries = execute_piranha(&piranha_arguments);
+  let number_of_rewrites: usize = summaries.iter().map(|s| s.rewrites().len()).sum();
+
+  let flag_report: serde_json::Value =
+    serde_json::from_str(&fs::read_to_string(&path_to_flag_report).unwrap()).unwrap();
+  let flag_cleanups = flag_report["flags"].as_array().unwrap();
+  let flags = flag_cleanups.iter().map(|f| &f["flag"]).collect::<Vec<_>>();
+  assert_eq!(flags, vec!["false", "true"]);
+  let number_of_edits: u64 = flag_cleanups
+    .iter()
+    .map(|f| f["edits"].as_u64().unwrap())
+    .sum();
+  assert_eq!(number_of_edits as usize, number_of_rewrites);
+  assert_eq!(
+    flag_cleanups[0]["packages"][0]["files"][0]["path"],
+    "sample.go"
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that each report of a run embeds the same manifest, i.e. the version of Piranha,
+/// the hashes of the built-in rule packs, of the configuration and of the flags of the run.
+#[test]
+fn test_run_manifest() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("short_circuit_assignment");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let report = |name: &str| temp_dir.path().join(name).to_str().unwrap().to_string();
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .path_to_run_report(Some(report("run_report.json")))
+    .path_to_flag_report(Some(report("flag_report.json")))
+    .path_to_package_heatmap(Some(report("heatmap.json")))
+    .path_to_sarif_report(Some(report("report.sarif")))
+    .path_to_junit_report(Some(report("junit.xml")))
+    .build();
+
+  execute_piranha(&piranha_arguments);
+
+  let read_json = |name: &str| -> serde_json::Value {
+    serde_json::from_str(&fs::read_to_string(report(name)).unwrap()).unwrap()
+  };
+  let manifest = read_json("run_report.json")["manifest"].clone();
+  assert_eq!(manifest["piranha_version"], env!("CARGO_PKG_VERSION"));
+  assert_eq!(manifest["language"], GO);
+  assert!(manifest["config_hash"].is_string());
+  assert!(manifest["arguments_hash"].is_string());
+  assert_eq!(manifest["arguments"]["substitutions"]["treated"], "true");
+  assert!(manifest["rule_packs"]
+    .as_array()
+    .unwrap()
+    .iter()
+    .any(|pack| pack["name"] == "rules.toml"));
+  assert_eq!(read_json("flag_report.json")["manifest"], manifest);
+  assert_eq!(read_json("heatmap.json")["manifest"], manifest);
+  assert_eq!(
+    read_json("report.sarif")["runs"][0]["properties"]["manifest"],
+    manifest
+  );
+  let junit = fs::read_to_string(report("junit.xml")).unwrap();
+  assert!(junit.contains(&format!(
+    "<property name=\"piranha.arguments_hash\" value=\"{}\"/>",
+    manifest["arguments_hash"].as_str().unwrap()
+  )));
+  // The test cases are named after the path of the files relative to the code base
+  assert!(junit.contains("<testcase classname=\"piranha\" name=\"sample.go\""));
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that the `check` of a golden corpus reports no diff when the output matches its `expected` folder,
+/// and the diff from the expected code base otherwise (here, its `input` folder), without editing the code base.
+#[test]
+fn test_check_against_expected() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("statement_cleanup");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .cleanup_comments(true)
+    .build();
+
+  let diff = check_piranha(&piranha_arguments, _path.join("expected").to_str().unwrap());
+  assert!(diff.is_empty(), "{diff}");
+
+  let diff = check_piranha(&piranha_arguments, _path.join("input").to_str().unwrap());
+  assert!(diff.contains("--- a/sample.go\n+++ b/sample.go\n"));
+
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    fs::read_to_string(_path.join("input").join("sample.go")).unwrap()
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that a plan leaves the code base untouched, and that applying it edits the files exactly as
+/// executing Piranha would. A plan is not applied once a planned file changed.
+#[test]
+fn test_plan_and_apply() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("statement_cleanup");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let executed_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let path_to_plan = temp_dir.path().join("plan.json");
+  let path_to_plan = path_to_plan.to_str().unwrap();
+
+  let piranha_arguments = |path_to_codebase: &Path| {
+    PiranhaArgumentsBuilder::default()
+      .path_to_codebase(path_to_codebase.to_str().unwrap().to_string())
+      .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+      .language(PiranhaLanguage::from(GO))
+      .substitutions(substitutions! {
+        "treated" => "true",
+        "treated_complement" => "false"
+      })
+      .cleanup_comments(true)
+      .build()
+  };
+
+  assert_eq!(
+    plan_piranha(&piranha_arguments(temp_dir.path()), path_to_plan),
+    1
+  );
+  let original = fs::read_to_string(_path.join("input").join("sample.go")).unwrap();
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    original
+  );
+
+  assert!(apply_plan(path_to_plan).is_empty());
+  _ = execute_piranha(&piranha_arguments(executed_dir.path()));
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    fs::read_to_string(executed_dir.path().join("sample.go")).unwrap()
+  );
+
+  // The code base changed since the plan was made
+  fs::write(temp_dir.path().join("sample.go"), &original).unwrap();
+  assert_eq!(
+    plan_piranha(&piranha_arguments(temp_dir.path()), path_to_plan),
+    1
+  );
+  fs::write(temp_dir.path().join("sample.go"), format!("{original}\n")).unwrap();
+  assert_eq!(apply_plan(path_to_plan), vec!["sample.go".to_string()]);
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    format!("{original}\n")
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+  executed_dir.close().unwrap();
+}
+
+/// This test checks that the quick-fix bundle exported by a dry run fixes the code base as Piranha would,
+/// and that it is not applied once a file changed since the analysis.
+#[test]
+fn test_quick_fix_bundle() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("statement_cleanup");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let executed_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let bundle_dir = TempDir::new("quick_fix").unwrap();
+  let path_to_bundle = bundle_dir.path().join("quick_fixes.json");
+  let path_to_bundle = path_to_bundle.to_str().unwrap();
+
+  let piranha_arguments = |path_to_codebase: &Path, dry_run: bool| {
+    PiranhaArgumentsBuilder::default()
+      .path_to_codebase(path_to_codebase.to_str().unwrap().to_string())
+      .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+      .language(PiranhaLanguage::from(GO))
+      .substitutions(substitutions! {
+        "treated" => "true",
+        "treated_complement" => "false"
+      })
+      .cleanup_comments(true)
+      .dry_run(dry_run)
+      .path_to_quick_fix_bundle(Some(path_to_bundle.to_string()))
+      .build()
+  };
+
+  _ = execute_piranha(&piranha_arguments(temp_dir.path(), true));
+  let original = fs::read_to_string(_path.join("input").join("sample.go")).unwrap();
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    original
+  );
+
+  // The code base changed since the analysis
+  fs::write(temp_dir.path().join("sample.go"), format!("{original}\n")).unwrap();
+  assert_eq!(
+    apply_quick_fix_bundle(path_to_bundle),
+    vec!["sample.go".to_string()]
+  );
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    format!("{original}\n")
+  );
+
+  fs::write(temp_dir.path().join("sample.go"), &original).unwrap();
+  assert!(apply_quick_fix_bundle(path_to_bundle).is_empty());
+  _ = execute_piranha(&piranha_arguments(executed_dir.path(), false));
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    fs::read_to_string(executed_dir.path().join("sample.go")).unwrap()
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+  executed_dir.close().unwrap();
+}
+
+/// This test checks that the diffs of a dry run are filtered by rule name, flag and path.
+#[test]
+fn test_dry_run_filters() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("statement_cleanup");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+
+  let dry_run_arguments = |rules: Vec<&str>, flags: Vec<&str>, paths: Vec<&str>| {
+    PiranhaArgumentsBuilder::default()
+      .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+      .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+      .language(PiranhaLanguage::from(GO))
+      .substitutions(substitutions! {
+        "treated" => "true",
+        "treated_complement" => "false"
+      })
+      .dry_run(true)
+      .dry_run_rules(rules.iter().map(|r| r.to_string()).collect())
+      .dry_run_flags(flags.iter().map(|f| f.to_string()).collect())
+      .dry_run_paths(paths.iter().map(|p| Pattern::new(p).unwrap()).collect())
+      .build()
+  };
+  let summaries = execute_piranha(&dry_run_arguments(vec![], vec![], vec![]));
+  let diff = |rules: Vec<&str>, flags: Vec<&str>, paths: Vec<&str>| {
+    get_dry_run_diff(&dry_run_arguments(rules, flags, paths), &summaries)
+  };
+
+  let all = diff(vec![], vec![], vec![]);
+  assert!(all.contains("--- a/sample.go\n+++ b/sample.go\n"));
+  assert_eq!(diff(vec!["true_flag"], vec!["true"], vec!["**/*.go"]), all);
+  assert!(diff(vec!["delete_unused_fallback_function"], vec![], vec![]).is_empty());
+  assert!(diff(vec![], vec!["unknownFlag"], vec![]).is_empty());
+  assert!(diff(vec![], vec![], vec!["**/other/**"]).is_empty());
+
+  // The dry run does not edit the file
+  assert_eq!(
+    fs::read_to_string(temp_dir.path().join("sample.go")).unwrap(),
+    fs::read_to_string(_path.join("input").join("sample.go")).unwrap()
+  );
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+#[test]
+fn test_removed_flag_usage_locations() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("structural_find")
+    .join("removed_flag_usage");
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(_path.join("input").to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "flag_api" => "BoolValue"
+    })
+    .removed_flags(vec!["stale_flag".to_string(), "payments.stale".to_string()])
+    .dry_run(true)
+    .build();
+  let summaries = execute_piranha(&piranha_arguments);
+
+  assert_eq!(
+    get_removed_flag_usages(&piranha_arguments, &summaries),
+    vec![
+      "sample.go:16:7: removed flag \"payments.stale\" (removed_flag_constant)",
+      "sample.go:22:12: removed flag \"stale_flag\" (removed_flag_api_call)",
+      "sample.go:26:12: removed flag \"stale_flag\" (removed_flag_api_call)",
+      "sample.go:31:12: removed flag \"stale_flag\" (removed_flag_api_call)",
+    ]
+  );
+}
+
+/// This test checks that the mentions of the removed flags left in the comments, the string literals and the struct tags
+/// are reported as remnants (once each), unlike the usages and the mentions of other identifiers.
+#[test]
+fn test_flag_remnants() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("structural_find")
+    .join("flag_remnants");
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(_path.join("input").to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "flag_api" => "BoolValue"
+    })
+    .removed_flags(vec!["stale_flag".to_string()])
+    .scan_flag_remnants(true)
+    .dry_run(true)
+    .build();
+  let summaries = execute_piranha(&piranha_arguments);
+
+  assert_eq!(
+    get_flag_remnants(&piranha_arguments, &summaries),
+    vec![
+      "sample.go:19:19: remnant of the flag \"stale_flag\" in a struct tag (flag_remnant_struct_tag)",
+      "sample.go:23:1: remnant of the flag \"stale_flag\" in a comment (flag_remnant_comment)",
+      "sample.go:25:16: remnant of the flag \"stale_flag\" in a string literal (flag_remnant_string_literal)",
+    ]
+  );
+  assert_eq!(
+    get_removed_flag_usages(&piranha_arguments, &summaries),
+    vec!["sample.go:28:5: removed flag \"stale_flag\" (removed_flag_api_call)"]
+  );
+}
+
+/// This test checks that with a CODEOWNERS file, the patch is split into one patch file per owner.
+#[test]
+fn test_patch_split_by_code_owners() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("statement_cleanup");
+  let temp_dir = copy_folder_to_temp_dir(&_path.join("input"));
+  let path_to_codeowners = temp_dir.path().join("CODEOWNERS");
+  fs::write(&path_to_codeowners, "*.go @org/flags\n").unwrap();
+  let path_to_patch = temp_dir.path().join("edits.patch");
+
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(temp_dir.path().to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .dry_run(true)
+    .path_to_patch(Some(path_to_patch.to_str().unwrap().to_string()))
+    .path_to_codeowners(Some(path_to_codeowners.to_str().unwrap().to_string()))
+    .build();
+  let _ = execute_piranha(&piranha_arguments);
+
+  let patch = fs::read_to_string(temp_dir.path().join("edits.org_flags.patch")).unwrap();
+  assert!(patch.contains("--- a/sample.go\n+++ b/sample.go\n"));
+  assert!(!path_to_patch.exists());
+  assert!(!temp_dir.path().join("edits.unowned.patch").exists());
+  // Delete temp_dir
+  temp_dir.close().unwrap();
+}
+
+/// This test checks that the calls of a constant-returning function are only replaced in its package,
+/// i.e. not the calls of the function of the same name of another package (`flags`).
+#[test]
+fn test_return_tuple_cleanup_other_package() {
+  initialize();
+  let _path = PathBuf::from("test-resources")
+    .join(GO)
+    .join("feature_flag")
+    .join("builtin_rules")
+    .join("return_tuple_cleanup_other_package");
+  let piranha_arguments = PiranhaArgumentsBuilder::default()
+    .path_to_codebase(_path.join("input").to_str().unwrap().to_string())
+    .path_to_configurations(_path.join("configurations").to_str().unwrap().to_string())
+    .language(PiranhaLanguage::from(GO))
+    .substitutions(substitutions! {
+      "treated" => "true",
+      "treated_complement" => "false"
+    })
+    .dry_run(true)
+    .build();
+  let summaries = execute_piranha(&piranha_arguments);
+
+  assert_eq!(summaries.len(), 1);
+  assert!(summaries[0].path().ends_with("sample.go"));
+  assert!(eq_without_whitespace(
+    summaries[0].content(),
+    &fs::read_to_string(_path.join("expected").join("sample.go")).unwrap()
+  ));
 }
@@ -21,9 +21,13 @@ use crate::{
 use itertools::Itertools;
 use log::debug;
 use pyo3::prelude::pyclass;
+use regex::Regex;
 use serde_derive::Deserialize;
 use std::collections::HashMap;
-use tree_sitter::{InputEdit, Node, Point, Query, QueryCapture, QueryCursor, Range};
+use tree_sitter::{
+  InputEdit, Node, Point, Query, QueryCapture, QueryCursor, QueryMatch, QueryPredicateArg, Range,
+};
+use tree_sitter_traversal::{traverse, Order};
 
 /// Applies the query upon the given node, and gets all the matches
 /// # Arguments
@@ -120,6 +124,10 @@ fn _get_query_capture_groups<'a>(
   // we group the query match instances based on the range of the outermost node they matched.
   let mut query_matches_by_node_range: HashMap<Range, Vec<Vec<QueryCapture>>> = HashMap::new();
   for query_match in query_matches {
+    // tree-sitter does not evaluate the predicates it does not know (i.e. `#eval-eq?`, `#not-shadowed?` and `#not-within?`)
+    if !satisfies_custom_predicates(query, &query_match, source_code) {
+      continue;
+    }
     // The first capture in any query match is it's outermost tag.
     // Ensure the outermost s-expression for is tree-sitter query is tagged.
     if let Some(captured_node) = query_match.captures.first() {
@@ -132,6 +140,217 @@ fn _get_query_capture_groups<'a>(
   query_matches_by_node_range
 }
 
+/// The predicate comparing the value of the captured string constant expression with a string,
+/// e.g. `(#eval-eq? @flag_name "team.staleFlag")` matches `prefix + "staleFlag"` when `const prefix = "team."`.
+pub(crate) const EVAL_EQ_PREDICATE: &str = "eval-eq?";
+
+/// The maximum number of constants followed when evaluating a string constant expression (e.g. cyclic constants).
+const MAX_EVAL_DEPTH: u8 = 8;
+
+/// The predicate checking that the captured identifier is not shadowed by a local declaration
+/// (e.g. `staleFlag := otherValue` or a parameter `staleFlag`), i.e. it refers to the package level declaration.
+/// For instance, `(#not-shadowed? @arg_id)` does not match the usages of a flag constant in the functions redeclaring it (as in Go).
+pub(crate) const NOT_SHADOWED_PREDICATE: &str = "not-shadowed?";
+
+/// The predicate checking that none of the enclosing nodes of the captured node matches the regex,
+/// e.g. `(#not-within? @reference "^assert[.]Panics[(]")` does not match the references within a panic assertion.
+pub(crate) const NOT_WITHIN_PREDICATE: &str = "not-within?";
+
+/// Checks that the captured nodes of the query match satisfy the custom predicates (i.e. `#eval-eq?`, `#not-shadowed?` and `#not-within?`).
+fn satisfies_custom_predicates(query: &Query, query_match: &QueryMatch, source_code: &str) -> bool {
+  query
+    .general_predicates(query_match.pattern_index)
+    .iter()
+    .all(|predicate| {
+      match (
+        predicate.operator.as_ref(),
+        predicate.args.first(),
+        predicate.args.get(1),
+      ) {
+        (
+          EVAL_EQ_PREDICATE,
+          Some(QueryPredicateArg::Capture(index)),
+          Some(QueryPredicateArg::String(expected)),
+        ) => query_match.nodes_for_capture_index(*index).all(|node| {
+          eval_string_constant(node, source_code, MAX_EVAL_DEPTH).as_deref()
+            == Some(expected.as_ref())
+        }),
+        (NOT_SHADOWED_PREDICATE, Some(QueryPredicateArg::Capture(index)), None) => query_match
+          .nodes_for_capture_index(*index)
+          .all(|node| !is_shadowed(node, source_code)),
+        (
+          NOT_WITHIN_PREDICATE,
+          Some(QueryPredicateArg::Capture(index)),
+          Some(QueryPredicateArg::String(pattern)),
+        ) => Regex::new(pattern).map_or(false, |regex| {
+          query_match
+            .nodes_for_capture_index(*index)
+            .all(|node| !is_within(node, source_code, &regex))
+        }),
+        (EVAL_EQ_PREDICATE, _, _)
+        | (NOT_SHADOWED_PREDICATE, _, _)
+        | (NOT_WITHIN_PREDICATE, _, _) => false,
+        _ => true,
+      }
+    })
+}
+
+/// Checks if the code snippet of any of the enclosing nodes of `node` matches the `regex`.
+fn is_within(node: Node, source_code: &str, regex: &Regex) -> bool {
+  let mut current_node = node;
+  while let Some(parent) = current_node.parent() {
+    if regex.is_match(parent.utf8_text(source_code.as_bytes()).unwrap_or_default()) {
+      return true;
+    }
+    current_node = parent;
+  }
+  false
+}
+
+/// Checks if the identifier `node` refers to a local declaration, i.e. a parameter of an enclosing function, or a variable
+/// (or constant) declared before `node` by an enclosing block or statement (e.g. the initializer of an `if` statement).
+/// The identifiers declared by a local declaration (e.g. the left hand side of `staleFlag := otherValue`) are shadowed as well.
+/// The enclosing scopes are traversed up to the package level declarations (as in Go).
+pub(crate) fn is_shadowed(node: Node, source_code: &str) -> bool {
+  if node.kind() != "identifier" {
+    return false;
+  }
+  let name = node.utf8_text(source_code.as_bytes()).unwrap_or_default();
+  let mut scope = node;
+  while let Some(parent) = scope.parent() {
+    if parent.kind() == "source_file" {
+      return false;
+    }
+    let is_local_declaration = parent.parent().map_or(false, |p| p.kind() != "source_file");
+    let is_alias = parent.kind() == "type_switch_statement"
+      && parent
+        .child_by_field_name("alias")
+        .map_or(false, |alias| get_identifiers(alias).contains(&node));
+    if is_alias || (is_local_declaration && get_declared_identifiers(parent).contains(&node)) {
+      return true;
+    }
+    let mut cursor = parent.walk();
+    let declared_identifiers = parent
+      .named_children(&mut cursor)
+      // The scope of a local declaration starts once it is declared (e.g. `staleFlag := staleFlag + "_v2"`)
+      .filter(|child| child.end_byte() <= node.start_byte())
+      .flat_map(get_declared_identifiers)
+      .chain(
+        // E.g. `switch staleFlag := v.(type) { ... }`
+        parent
+          .child_by_field_name("alias")
+          .filter(|_| parent.kind() == "type_switch_statement")
+          .into_iter()
+          .flat_map(get_identifiers),
+      )
+      .collect_vec();
+    if declared_identifiers
+      .iter()
+      .any(|identifier| identifier.utf8_text(source_code.as_bytes()) == Ok(name))
+    {
+      return true;
+    }
+    scope = parent;
+  }
+  false
+}
+
+/// Returns the identifiers declared by the `declaration` (e.g. a short variable declaration, or the parameters of a function).
+fn get_declared_identifiers(declaration: Node) -> Vec<Node> {
+  let mut cursor = declaration.walk();
+  let defines = declaration
+    .children(&mut cursor)
+    .any(|child| child.kind() == ":=");
+  match declaration.kind() {
+    "short_var_declaration" => declaration
+      .child_by_field_name("left")
+      .map(get_identifiers)
+      .unwrap_or_default(),
+    // E.g. `for i, staleFlag := range flags { ... }` or `case staleFlag := <-flags:`
+    "range_clause" | "receive_statement" if defines => declaration
+      .child_by_field_name("left")
+      .map(get_identifiers)
+      .unwrap_or_default(),
+    "for_clause" => declaration
+      .child_by_field_name("initializer")
+      .map(get_declared_identifiers)
+      .unwrap_or_default(),
+    "var_declaration" | "const_declaration" | "parameter_list" => {
+      let mut cursor = declaration.walk();
+      declaration
+        .named_children(&mut cursor)
+        .flat_map(get_names)
+        .collect_vec()
+    }
+    _ => vec![],
+  }
+}
+
+/// Returns the names declared by the spec (e.g. `a, b = 1, 2`) or the parameter declaration (e.g. `a, b int`).
+fn get_names(spec: Node) -> Vec<Node> {
+  let mut cursor = spec.walk();
+  spec
+    .children_by_field_name("name", &mut cursor)
+    .collect_vec()
+}
+
+/// Returns the identifiers of the expression list (e.g. the left hand side of a short variable declaration).
+fn get_identifiers(expression_list: Node) -> Vec<Node> {
+  let mut cursor = expression_list.walk();
+  expression_list
+    .named_children(&mut cursor)
+    .filter(|child| child.kind() == "identifier")
+    .collect_vec()
+}
+
+/// Evaluates the string constant expression of the node, i.e. a concatenation (`+`) of string literals and string constants.
+/// The constants are resolved against the package level `const` declarations of the same file (as in Go),
+/// the identifiers referring to a local declaration (e.g. a function local `const prefix = "other."`) are not evaluated.
+///
+/// # Returns
+/// The value of the expression, `None` if it is not a string constant expression (or a constant cannot be resolved).
+pub(crate) fn eval_string_constant(node: Node, source_code: &str, depth: u8) -> Option<String> {
+  if depth == 0 {
+    return None;
+  }
+  let text = node.utf8_text(source_code.as_bytes()).ok()?;
+  match node.kind() {
+    "interpreted_string_literal" | "raw_string_literal" if text.len() >= 2 => {
+      Some(text[1..text.len() - 1].to_string())
+    }
+    "parenthesized_expression" | "expression_list" if node.named_child_count() == 1 => {
+      eval_string_constant(node.named_child(0)?, source_code, depth)
+    }
+    "binary_expression" if node.child_by_field_name("operator")?.kind() == "+" => {
+      let left = eval_string_constant(node.child_by_field_name("left")?, source_code, depth)?;
+      let right = eval_string_constant(node.child_by_field_name("right")?, source_code, depth)?;
+      Some(format!("{left}{right}"))
+    }
+    "identifier" if !is_shadowed(node, source_code) => {
+      let mut root = node;
+      while let Some(parent) = root.parent() {
+        root = parent;
+      }
+      let value = traverse(root.walk(), Order::Pre)
+        .filter(|n| n.kind() == "const_spec")
+        // The package level declarations, i.e. `const_declaration` of the `source_file`
+        .filter(|n| {
+          n.parent()
+            .and_then(|declaration| declaration.parent())
+            .map_or(false, |p| p.kind() == "source_file")
+        })
+        .find(|n| {
+          n.child_by_field_name("name")
+            .and_then(|name| name.utf8_text(source_code.as_bytes()).ok())
+            .map_or(false, |name| name == text)
+        })?
+        .child_by_field_name("value")?;
+      eval_string_constant(value, source_code, depth - 1)
+    }
+    _ => None,
+  }
+}
+
 // Join code snippets corresponding to the corresponding to the same tag with `\n`.
 // This scenario occurs when we use the `*` or the `+` quantifier in the tree-sitter query
 // Look at - cleanup_riles/java/rules:remove_unnecessary_nested_block
@@ -230,7 +449,7 @@ pub(crate) fn get_tree_sitter_edit(code: String, edit: &Edit) -> (String, InputE
 }
 
 // Finds the position (col and row number) for a given offset.
-fn position_for_offset(input: &[u8], offset: usize) -> Point {
+pub(crate) fn position_for_offset(input: &[u8], offset: usize) -> Point {
   let mut result = Point { row: 0, column: 0 };
   for c in &input[0..offset] {
     if *c as char == '\n' {
@@ -268,20 +487,45 @@ pub(crate) fn get_node_for_range(root_node: Node, start_byte: usize, end_byte: u
     .unwrap()
 }
 
+/// Returns the nearest ancestor of `node` whose text differs from the text of `node`.
+/// Note that the ancestors are traversed iteratively, since the tree may be deeply nested.
 fn get_non_str_eq_parent(node: Node, source_code: String) -> Option<Node> {
-  if let Some(parent) = node.parent() {
-    if !eq_without_whitespace(
-      parent.utf8_text(source_code.as_bytes()).unwrap(),
-      node.utf8_text(source_code.as_bytes()).unwrap(),
-    ) {
+  let node_text = node.utf8_text(source_code.as_bytes()).unwrap();
+  let mut current = node;
+  while let Some(parent) = current.parent() {
+    if !eq_without_whitespace(parent.utf8_text(source_code.as_bytes()).unwrap(), node_text) {
       return Some(parent);
-    } else {
-      return get_non_str_eq_parent(parent, source_code);
     }
+    current = parent;
   }
   None
 }
 
+/// Returns the depth of the deepest descendant of `node` (`node` itself is at depth 0).
+/// The tree is traversed iteratively (with a cursor), so that it does not overflow the stack for deeply nested trees.
+pub(crate) fn get_max_depth(node: Node) -> usize {
+  let mut cursor = node.walk();
+  let (mut depth, mut max_depth) = (0, 0);
+  loop {
+    if cursor.goto_first_child() {
+      depth += 1;
+      max_depth = max_depth.max(depth);
+      continue;
+    }
+    // Backtrack to the nearest ancestor (within `node`) with a next sibling
+    loop {
+      if depth == 0 {
+        return max_depth;
+      }
+      if cursor.goto_next_sibling() {
+        break;
+      }
+      cursor.goto_parent();
+      depth -= 1;
+    }
+  }
+}
+
 /// Returns the node, its parent, grand parent and great grand parent
 pub(crate) fn get_context(prev_node: Node<'_>, source_code: String, count: u8) -> Vec<Node<'_>> {
   let mut output = Vec::new();
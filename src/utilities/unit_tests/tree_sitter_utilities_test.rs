@@ -12,11 +12,19 @@ Copyright (c) 2023 Uber Technologies, Inc.
 */
 use std::collections::HashMap;
 
+use itertools::Itertools;
+
 use tree_sitter::Query;
 
 use crate::{
-  models::{default_configs::JAVA, language::PiranhaLanguage},
-  utilities::{tree_sitter_utilities::get_all_matches_for_query, Instantiate},
+  models::{
+    default_configs::{GO, JAVA},
+    language::PiranhaLanguage,
+  },
+  utilities::{
+    tree_sitter_utilities::{get_all_matches_for_query, get_max_depth},
+    Instantiate,
+  },
 };
 
 use super::TSQuery;
@@ -125,6 +133,304 @@ fn test_get_all_matches_for_query_negative() {
   assert!(matches.is_empty());
 }
 
+#[test]
+fn test_get_all_matches_for_query_eval_eq() {
+  let source_code = r#"
+      package flags
+
+      const base = "team"
+      const prefix = base + "."
+
+      const (
+        staleFlag = prefix + "staleFlag"
+        otherFlag = prefix + "otherFlag"
+        literalFlag = "team.staleFlag"
+        parenthesizedFlag = (base + ".") + `staleFlag`
+        unresolvedFlag = unknown + "staleFlag"
+      )
+    "#;
+  let language = PiranhaLanguage::from(GO);
+  let query = Query::new(
+    *language.language(),
+    r#"(
+        (const_spec
+          name: (identifier) @name
+          value: (expression_list) @value
+        ) @const_spec
+        (#eval-eq? @value "team.staleFlag")
+      )"#,
+  )
+  .unwrap();
+
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let ast = parser
+    .parse(source_code, None)
+    .expect("Could not parse code");
+  let node = ast.root_node();
+
+  let matches = get_all_matches_for_query(&node, source_code.to_string(), &query, true, None);
+  let names = matches
+    .iter()
+    .map(|m| m.matches()["name"].clone())
+    .sorted()
+    .collect_vec();
+  assert_eq!(names, vec!["literalFlag", "parenthesizedFlag", "staleFlag"]);
+}
+
+#[test]
+fn test_get_all_matches_for_query_eval_eq_local_constants() {
+  let source_code = r#"
+      package flags
+
+      const prefix = "team."
+
+      func localConstant() {
+        const prefix = "other."
+        use(prefix + "staleFlag")
+      }
+
+      func packageConstant() {
+        use(prefix + "staleFlag")
+      }
+
+      func otherLocalConstant() {
+        const base = "team."
+      }
+
+      func unresolvedConstant() {
+        use(base + "staleFlag")
+      }
+    "#;
+  let language = PiranhaLanguage::from(GO);
+  let query = Query::new(
+    *language.language(),
+    r#"(
+        (call_expression
+          arguments: (argument_list (_) @value)
+        ) @call
+        (#eval-eq? @value "team.staleFlag")
+      )"#,
+  )
+  .unwrap();
+
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let ast = parser
+    .parse(source_code, None)
+    .expect("Could not parse code");
+  let node = ast.root_node();
+
+  let matches = get_all_matches_for_query(&node, source_code.to_string(), &query, true, None);
+  let lines = matches
+    .iter()
+    .map(|m| m.range().start_point.row)
+    .sorted()
+    .collect_vec();
+  // Neither the function local constant `prefix`, nor the function local constant `base` of another function are evaluated
+  assert_eq!(lines, vec![11]);
+}
+
+#[test]
+#[should_panic(expected = "The predicate #eval-eq? is not supported for the language java")]
+fn test_eval_eq_unsupported_language() {
+  PiranhaLanguage::from(JAVA).create_query(
+    r#"(
+        (string_literal) @value
+        (#eval-eq? @value "team.staleFlag")
+      )"#
+      .to_string(),
+  );
+}
+
+#[test]
+fn test_get_all_matches_for_query_not_shadowed() {
+  let source_code = r#"
+      package flags
+
+      const staleFlag = "staleFlag"
+
+      func packageLevel() {
+        use(staleFlag)
+      }
+
+      func shortVarDeclaration(other string) {
+        use(staleFlag)
+        staleFlag := staleFlag + other
+        if other != "" {
+          use(staleFlag)
+        }
+      }
+
+      func parameter(staleFlag string) {
+        func() {
+          use(staleFlag)
+        }()
+      }
+
+      func statements(flags []string, values chan string, v interface{}) {
+        for _, staleFlag := range flags {
+          use(staleFlag)
+        }
+        if staleFlag, ok := v.(string); ok {
+          use(staleFlag)
+        }
+        switch staleFlag := v.(type) {
+        case string:
+          use(staleFlag)
+        }
+        select {
+        case staleFlag := <-values:
+          use(staleFlag)
+        }
+        use(staleFlag)
+      }
+    "#;
+  let language = PiranhaLanguage::from(GO);
+  let query = Query::new(
+    *language.language(),
+    r#"(
+        (call_expression
+          function: (identifier) @function
+          arguments: (argument_list (identifier) @argument)
+        ) @call
+        (#eq? @function "use")
+        (#eq? @argument "staleFlag")
+        (#not-shadowed? @argument)
+      )"#,
+  )
+  .unwrap();
+
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let ast = parser
+    .parse(source_code, None)
+    .expect("Could not parse code");
+  let node = ast.root_node();
+
+  let matches = get_all_matches_for_query(&node, source_code.to_string(), &query, true, None);
+  // The (one-based) lines of the usages referring to the package level constant
+  let lines = matches
+    .iter()
+    .map(|m| m.range().start_point.row + 1)
+    .sorted()
+    .collect_vec();
+  assert_eq!(lines, vec![7, 11, 39]);
+}
+
+#[test]
+fn test_get_all_matches_for_query_not_shadowed_local_declarations() {
+  let source_code = r#"
+      package flags
+
+      var staleFlag = "staleFlag"
+
+      func declarations(flags []string, v interface{}) {
+        staleFlag := "local"
+        var other, staleFlag = 1, 2
+        for _, staleFlag := range flags {
+        }
+        switch staleFlag := v.(type) {
+        }
+      }
+
+      func parameter(staleFlag string) {
+      }
+
+      func usage() {
+        staleFlag = "updated"
+      }
+      "#;
+  let language = PiranhaLanguage::from(GO);
+  let query = Query::new(
+    *language.language(),
+    r#"(
+        (identifier) @identifier
+        (#eq? @identifier "staleFlag")
+        (#not-shadowed? @identifier)
+      )"#,
+  )
+  .unwrap();
+
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let ast = parser
+    .parse(source_code, None)
+    .expect("Could not parse code");
+  let node = ast.root_node();
+
+  let matches = get_all_matches_for_query(&node, source_code.to_string(), &query, true, None);
+  // The declaration of the package level variable and its usage
+  let lines = matches
+    .iter()
+    .map(|m| m.range().start_point.row + 1)
+    .sorted()
+    .collect_vec();
+  assert_eq!(lines, vec![4, 19]);
+}
+
+#[test]
+fn test_get_all_matches_for_query_not_within() {
+  let source_code = r#"
+      package flags
+
+      func TestOldPath(t *testing.T) {
+        assert.Panics(t, oldPath)
+        assert.Panics(t, func() { oldPath() })
+        assert.NotNil(t, oldPath)
+        handlers := []func(){oldPath}
+      }
+    "#;
+  let language = PiranhaLanguage::from(GO);
+  let query = Query::new(
+    *language.language(),
+    r#"(
+        (identifier) @reference
+        (#eq? @reference "oldPath")
+        (#not-within? @reference "^assert[.]Panics[(]")
+      )"#,
+  )
+  .unwrap();
+
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let ast = parser
+    .parse(source_code, None)
+    .expect("Could not parse code");
+  let node = ast.root_node();
+
+  let matches = get_all_matches_for_query(&node, source_code.to_string(), &query, true, None);
+  // The (one-based) lines of the references outside the panic assertions
+  let lines = matches
+    .iter()
+    .map(|m| m.range().start_point.row + 1)
+    .sorted()
+    .collect_vec();
+  assert_eq!(lines, vec![7, 8]);
+}
+
+#[test]
+fn test_get_max_depth() {
+  // Each pair of parentheses nests the literal one level deeper
+  let nested_code = |n: usize| {
+    format!(
+      "package main\n\nvar x = {}1{}\n",
+      "(".repeat(n),
+      ")".repeat(n)
+    )
+  };
+  let mut parser = PiranhaLanguage::from(GO).parser();
+  let mut max_depth = |n: usize| {
+    let ast = parser
+      .parse(nested_code(n), None)
+      .expect("Could not parse code");
+    get_max_depth(ast.root_node())
+  };
+  let shallow = max_depth(1);
+  // Does not overflow the stack for deeply nested trees
+  assert_eq!(max_depth(5001), shallow + 5000);
+
+  let ast = parser.parse(nested_code(1), None).unwrap();
+  let literal = ast.root_node().descendant_for_byte_range(23, 24).unwrap();
+  assert_eq!(literal.kind(), "int_literal");
+  assert_eq!(get_max_depth(literal), 0);
+}
+
 #[test]
 fn test_instantiate() {
   let substitutions = HashMap::from([
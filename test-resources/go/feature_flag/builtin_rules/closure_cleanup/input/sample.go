@@ -0,0 +1,62 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func closure_disabled() {
+    check := func() bool {
+        return exp.BoolValue("false")
+    }
+    if check() {
+        fmt.Println("enabled")
+    } else {
+        fmt.Println("disabled")
+    }
+}
+
+func closure_enabled() string {
+    check := func() bool { return exp.BoolValue("true") }
+    if !check() {
+        return "disabled"
+    }
+    return "enabled"
+}
+
+// the closure is referenced as a function value, it should not be deleted
+func closure_passed_around() {
+    check := func() bool {
+        return exp.BoolValue("false")
+    }
+    run(check)
+}
+
+// the closure is aliased, it should not be deleted
+func closure_aliased() {
+    check := func() bool {
+        return exp.BoolValue("false")
+    }
+    other := check
+    run(other)
+}
+
+// the closure is stored in a composite literal, it should not be deleted
+func closure_in_composite_literal() {
+    check := func() bool {
+        return exp.BoolValue("false")
+    }
+    checks := []func() bool{check}
+    handlers := map[string]func() bool{"check": check}
+    runAll(checks, handlers)
+}
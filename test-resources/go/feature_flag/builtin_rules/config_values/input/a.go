@@ -0,0 +1,42 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func checkout(cfg Config) {
+    if exp.BoolValue("true") && cfg.Features.NewFlow {
+        fmt.Println("new flow")
+    } else {
+        fmt.Println("old flow")
+    }
+
+    if cfg.Features.LegacyFlow && exp.BoolValue("true") {
+        fmt.Println("legacy flow")
+    }
+
+    if exp.BoolValue("true") && cfg.Features.NewFlow && cfg.Features.NewFlow {
+        fmt.Println("new flow, twice")
+    }
+
+    // Not gated by the flag, hence left as is
+    if cfg.Features.NewFlow {
+        fmt.Println("new flow only")
+    }
+
+    // The value of this config field is not known
+    if exp.BoolValue("true") && cfg.Features.Other {
+        fmt.Println("other flow")
+    }
+}
@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func conditional() {
+    fmt.Println("enabled")
+    metrics.Inc("piranha.fallback", "true")
+}
+
+func negated() {
+    metrics.Inc("piranha.fallback", "false")
+    fmt.Println("enabled")
+}
+
+func guard() string {
+    metrics.Inc("piranha.fallback", "false")
+    return "enabled"
+}
+
+func chain(other bool) {
+    if other {
+        fmt.Println("other")
+    } else {
+        metrics.Inc("piranha.fallback", "false")
+    }
+}
+
+func withoutElse() {
+    fmt.Println("enabled")
+    fmt.Println("done")
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func else_if_not_enabled(somethingElse bool) {
+    if somethingElse {
+        fmt.Println("something else")
+    }
+}
+
+func else_if_disabled_with_alternative(somethingElse bool) {
+    if somethingElse {
+        fmt.Println("something else")
+    } else {
+        fmt.Println("keep")
+    }
+}
+
+func else_if_enabled(somethingElse bool) {
+    if somethingElse {
+        fmt.Println("something else")
+    } else {
+        fmt.Println("enabled")
+    }
+}
+
+func else_if_ladder(a bool, b bool) {
+    if a {
+        fmt.Println("a")
+    } else if b {
+        fmt.Println("b")
+    }
+}
+
+func else_if_with_initializer() {
+    if v := compute(); v > 0 {
+        fmt.Println("positive")
+    }
+}
+
+func nested_in_else_disabled(other bool) {
+    if other {
+        fmt.Println("other")
+    }
+}
+
+func nested_in_else_enabled(other bool) {
+    if other {
+        fmt.Println("other")
+    } else {
+        fmt.Println("enabled")
+    }
+}
+
+func nested_in_else_with_initializer() {
+    if v := compute(); v > 0 {
+        fmt.Println("positive")
+    }
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    _ "embed"
+    "fmt"
+)
+
+//go:embed templates/new.tmpl
+var newTemplate string
+
+//go:embed templates/old.tmpl
+var oldTemplate string
+
+//go:embed templates/banner.txt
+var banner string
+
+//go:embed templates/banner_v2.txt
+var bannerV2 string
+
+//go:embed templates/legacy.tmpl
+var legacyTemplate string
+
+//go:embed templates/Styles.css
+var OldStyles string
+
+//go:embed templates/help.txt
+var oldHelp string
+
+func render() {
+    var data string
+    if exp.BoolValue("true") {
+        data = newTemplate
+    } else {
+        data = oldTemplate
+    }
+    fmt.Println(data)
+}
+
+func renderBanner() {
+    var data string
+    if exp.BoolValue("false") {
+        data = bannerV2
+    } else {
+        data = banner
+    }
+    fmt.Println(data)
+}
+
+// `legacyTemplate` is still used
+func renderLegacy() {
+    var data string
+    if exp.BoolValue("true") {
+        data = newTemplate
+    } else {
+        data = legacyTemplate
+    }
+    fmt.Println(data, legacyTemplate)
+}
+
+// `OldStyles` is exported, it may be used by other packages
+func renderStyles() {
+    var data string
+    if exp.BoolValue("true") {
+        data = newTemplate
+    } else {
+        data = OldStyles
+    }
+    fmt.Println(data)
+}
+
+// `oldHelp` is still used by other.go
+func renderHelp() {
+    var data string
+    if exp.BoolValue("true") {
+        data = newTemplate
+    } else {
+        data = oldHelp
+    }
+    fmt.Println(data)
+}
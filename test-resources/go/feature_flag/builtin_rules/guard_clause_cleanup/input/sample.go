@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+// the guard clause is always taken, the statements after it are unreachable
+func guard_return_true() string {
+    if exp.BoolValue("true") {
+        return "enabled"
+    }
+    fmt.Println("unreachable")
+    return "disabled"
+}
+
+// the guard clause is never taken, it is deleted as a whole
+func guard_return_false() string {
+    if exp.BoolValue("false") {
+        return "enabled"
+    }
+    fmt.Println("reachable")
+    return "disabled"
+}
+
+func guard_return_true_with_else(a bool) string {
+    if exp.BoolValue("true") {
+        fmt.Println("enabled")
+        return "enabled"
+    } else if a {
+        return "a"
+    }
+    fmt.Println("unreachable")
+    return "disabled"
+}
+
+func guard_panic() {
+    enabled := exp.BoolValue("true")
+    if enabled {
+        panic("not supported")
+    }
+    fmt.Println("unreachable 1")
+    fmt.Println("unreachable 2")
+}
+
+func guard_continue(items []string) {
+    for i := 0; i < len(items); i++ {
+        if !exp.BoolValue("false") {
+            continue
+        }
+        fmt.Println(items[i])
+    }
+    fmt.Println("reachable")
+}
+
+func guard_in_switch(kind string) string {
+    switch kind {
+    case "a":
+        if exp.BoolValue("true") {
+            return "enabled"
+        }
+        fmt.Println("unreachable")
+        return "a"
+    default:
+        if exp.BoolValue("false") {
+            break
+        }
+        fmt.Println("reachable")
+    }
+    return kind
+}
+
+// only the statements following the guard clause in the same block are unreachable
+func nested_guard(a bool) string {
+    if a {
+        if exp.BoolValue("true") {
+            return "enabled"
+        }
+        fmt.Println("unreachable")
+    }
+    fmt.Println("reachable")
+    return "disabled"
+}
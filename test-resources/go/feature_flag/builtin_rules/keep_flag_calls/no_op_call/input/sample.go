@@ -0,0 +1,39 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func conditional() {
+    if exp.BoolValue("true") {
+        fmt.Println("enabled")
+    } else {
+        fmt.Println("disabled")
+    }
+}
+
+func variable() {
+    disabled := exp.BoolValue("false")
+    if disabled {
+        fmt.Println("disabled")
+    }
+    fmt.Println("done")
+}
+
+func guard() string {
+    if exp.BoolValue("false") {
+        return "disabled"
+    }
+    return "enabled"
+}
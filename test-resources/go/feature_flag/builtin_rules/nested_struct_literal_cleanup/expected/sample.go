@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+type Features struct {
+    NewFlow bool
+    Verbose bool
+}
+
+type Server struct {
+    Name     string
+    Features Features
+}
+
+var defaultServer = &Server{Name: "default", Features: Features{NewFlow: true}}
+
+func package_level() {
+    fmt.Println("new flow")
+}
+
+func local_pointer() {
+    s := &Server{Name: "local", Features: Features{Verbose: true, NewFlow: false}}
+    fmt.Println(s.Name, s.Features.Verbose)
+}
+
+func local_value() {
+    v := Server{Features: Features{NewFlow: true}}
+    fmt.Println(v.Name)
+}
+
+// The nested field is assigned, its reads should be retained
+func assigned() {
+    a := &Server{Features: Features{NewFlow: true}}
+    a.Features.NewFlow = compute()
+    if a.Features.NewFlow {
+        fmt.Println("new flow")
+    }
+}
+
+// The variable is addressed, its reads should be retained
+func addressed() {
+    b := Server{Features: Features{NewFlow: true}}
+    toggle(&b)
+    if b.Features.NewFlow {
+        fmt.Println("new flow")
+    }
+}
+
+// The variable is passed to a function, its reads should be retained
+func passed_as_argument() {
+    c := &Server{Features: Features{NewFlow: true}}
+    configure(c)
+    if c.Features.NewFlow {
+        fmt.Println("new flow")
+    }
+}
+
+// The local variable shadows the package level variable, its reads should be retained
+func shadowed() {
+    defaultServer := load()
+    if defaultServer.Features.NewFlow {
+        fmt.Println("new flow")
+    }
+}
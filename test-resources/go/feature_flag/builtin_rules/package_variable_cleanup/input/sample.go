@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	// enabled is the state of the stale flag
+	enabled        = exp.BoolValue("true")
+	requestTimeout = 10 * time.Second
+	// the number of retries
+	retries = 3
+)
+
+var (
+	disabled = exp.BoolValue("false")
+)
+
+var alsoEnabled = exp.BoolValue("true")
+
+// Exported variables may be used by other packages
+var Enabled = exp.BoolValue("true")
+
+func a() {
+	if enabled {
+		fmt.Println("enabled", requestTimeout)
+	}
+	if disabled {
+		fmt.Println("disabled", retries)
+	}
+	if alsoEnabled {
+		fmt.Println("also enabled")
+	}
+}
+
+// the parameter shadows the package level variable
+func b(enabled bool) {
+	if enabled {
+		fmt.Println("parameter")
+	}
+}
+
+// the local variable (declared in an outer block) shadows the package level variable
+func c() {
+	alsoEnabled := retries > 0
+	for i := 0; i < retries; i++ {
+		if alsoEnabled {
+			fmt.Println("local", i)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestProcessNew(t *testing.T) {
+    assert.NotPanics(t, processNew)
+}
+
+func TestProcessWithoutNewFlow(t *testing.T) {
+    require.NotNil(t, process)
+}
+
+func TestProcessWithNewFlow(t *testing.T) {
+    // the treated path panics, the assertion is kept
+    assert.Panics(t, func() {
+        panic("new flow")
+    })
+}
+
+func TestHelperStillUsed(t *testing.T) {
+    // the helper is still called, it is kept
+    oldFlow := func() {
+    }
+    oldFlow()
+    assert.Panics(t, oldFlow)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestProcessOldPanics(t *testing.T) {
+    assert.Panics(t, func() { processOld() })
+}
+
+func TestProcessNew(t *testing.T) {
+    assert.NotPanics(t, processNew)
+    require.NotPanics(t, processOld)
+}
+
+func TestProcessWithoutNewFlow(t *testing.T) {
+    assert.Panics(t, func() {
+        if !exp.BoolValue("true") {
+            panic("old flow")
+        }
+    })
+    oldFlow := func() {
+        if exp.BoolValue("false") {
+            panic("old flow")
+        }
+    }
+    assert.PanicsWithValue(t, "old flow", oldFlow)
+    require.NotNil(t, process)
+}
+
+func TestProcessWithNewFlow(t *testing.T) {
+    // the treated path panics, the assertion is kept
+    assert.Panics(t, func() {
+        if exp.BoolValue("true") {
+            panic("new flow")
+        }
+    })
+}
+
+func TestHelperStillUsed(t *testing.T) {
+    // the helper is still called, it is kept
+    oldFlow := func() {
+        if exp.BoolValue("false") {
+            panic("old flow")
+        }
+    }
+    oldFlow()
+    assert.Panics(t, oldFlow)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func process() {
+    if exp.BoolValue("true") {
+        defer func() {
+            if r := recover(); r != nil {
+                processOld()
+            }
+        }()
+        processNew()
+    } else {
+        processOld()
+    }
+}
+
+func processNew() {
+    fmt.Println("new")
+}
+
+func processOld() {
+    fmt.Println("old")
+}
+
+func render() {
+    if exp.BoolValue("true") {
+        defer func() {
+            if r := recover(); r != nil {
+                renderOld()
+            }
+        }()
+        renderNew()
+    }
+}
+
+func renderNew() {
+    fmt.Println("new")
+}
+
+// the old path is still referenced, it should not be deleted
+func renderOld() {
+    fmt.Println("old")
+}
+
+func renderAll() {
+    renderOld()
+}
+
+func store() {
+    if exp.BoolValue("false") {
+        defer func() {
+            if r := recover(); r != nil {
+                storeOld()
+            }
+        }()
+        storeNew()
+    } else {
+        storeOld()
+    }
+}
+
+func storeNew() {
+    fmt.Println("new")
+}
+
+func storeOld() {
+    fmt.Println("old")
+}
+
+func draw() {
+    if exp.BoolValue("true") {
+        defer func() {
+            if r := recover(); r != nil {
+                drawOld()
+            }
+        }()
+        drawNew()
+    }
+}
+
+func drawNew() {
+    fmt.Println("new")
+}
+
+// the old path is still referenced by other.go, it should not be deleted
+func drawOld() {
+    fmt.Println("old")
+}
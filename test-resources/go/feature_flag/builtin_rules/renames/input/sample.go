@@ -0,0 +1,36 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func checkout(cart Cart) {
+    if exp.BoolValue("true") {
+        newCheckoutFlow(cart)
+    } else {
+        fmt.Println("legacy checkout")
+    }
+}
+
+func serve() {
+    var h handlerV2
+    h.ServeV2()
+    fmt.Println("newCheckoutFlow")
+}
+
+// The local variable is not renamed, since it shadows the function
+func localFlow(cart Cart) {
+    newCheckoutFlow := cart.Items()
+    fmt.Println(newCheckoutFlow, legacyRegion())
+}
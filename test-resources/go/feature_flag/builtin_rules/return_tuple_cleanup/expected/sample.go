@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func isEnabled() (bool, error) {
+    return true, nil
+}
+
+func caller() error {
+    fmt.Println("enabled")
+    return nil
+}
+
+func callerIgnoringError() {
+}
+
+// the function is not constant-returning, its callers should not be updated
+func isDisabled(err error) (bool, error) {
+    return false, err
+}
+
+func otherCaller() error {
+    disabled, err := isDisabled(nil)
+    if err != nil {
+        return err
+    }
+    fmt.Println(disabled)
+    return nil
+}
+
+func load() (string, error) {
+    return "data", nil
+}
+
+// `err` is declared again after the call, the variable is kept
+func callerRedeclaringError() error {
+    var err error
+    if err != nil {
+        return err
+    }
+    data, err := load()
+    if err != nil {
+        return err
+    }
+    fmt.Println(true, data)
+    return nil
+}
+
+// `err` is declared before the call, the call assigns it
+func callerReusingError() error {
+    data, err := load()
+    if err != nil {
+        return err
+    }
+    err = nil
+    if err != nil {
+        return err
+    }
+    fmt.Println(true, data)
+    return nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func select_case_body(ch chan int, done chan bool) {
+    select {
+    case v := <-ch:
+        if exp.BoolValue("true") {
+            fmt.Println("enabled", v)
+        } else {
+            fmt.Println("disabled", v)
+        }
+    case <-done:
+        fmt.Println("done")
+    }
+}
+
+func select_empty_case_body(ch chan int, done chan bool) {
+    select {
+    case <-ch:
+        if exp.BoolValue("false") {
+            fmt.Println("disabled")
+        }
+    case <-done:
+        fmt.Println("done")
+    }
+}
+
+func select_empty_case_many_cases(ch chan int, done chan bool, quit chan bool) {
+    select {
+    case <-ch:
+        if exp.BoolValue("false") {
+            fmt.Println("disabled")
+        }
+    case <-done:
+        fmt.Println("done")
+    case <-quit:
+        fmt.Println("quit")
+    }
+}
+
+// the send is observable by the receiver, it should not be deleted
+func select_empty_send_case(ch chan int, done chan bool) {
+    select {
+    case ch <- 1:
+        if exp.BoolValue("false") {
+            fmt.Println("disabled")
+        }
+    case <-done:
+        fmt.Println("done")
+    }
+}
+
+func select_nil_channel(done chan bool) {
+    var newCh chan int
+    if exp.BoolValue("false") {
+        newCh = make(chan int)
+        go produce(newCh)
+    }
+    select {
+    case v := <-newCh:
+        fmt.Println(v)
+    case <-done:
+        fmt.Println("done")
+    }
+}
+
+// the channel is created outside the flag path, the case should not be deleted
+func select_channel_created(done chan bool) {
+    var newCh chan int
+    if exp.BoolValue("false") {
+        go produce(newCh)
+    }
+    newCh = make(chan int)
+    select {
+    case v := <-newCh:
+        fmt.Println(v)
+    case <-done:
+        fmt.Println("done")
+    }
+}
+
+func select_default_case(ch chan int) {
+    select {
+    case <-ch:
+        if exp.BoolValue("false") {
+            fmt.Println("disabled")
+        }
+    default:
+        if exp.BoolValue("true") {
+            fmt.Println("enabled")
+        }
+    }
+}
+
+// the case `<-ch` was already empty, it should not be deleted
+func select_already_empty_case(ch chan int, done chan bool) {
+    select {
+    case <-ch:
+    case <-done:
+        if exp.BoolValue("false") {
+            fmt.Println("disabled")
+        }
+        fmt.Println("done")
+    }
+}
+
+// the `break` terminates the `select`, it should not be simplified
+func select_break(ch chan int, done chan bool) {
+    for {
+        select {
+        case <-ch:
+            if exp.BoolValue("false") {
+                fmt.Println("disabled")
+            }
+        case <-done:
+            if ready() {
+                break
+            }
+            fmt.Println("done")
+        }
+    }
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+// `otherCheck()` is evaluated before the flag; it has to be preserved
+func call_and_disabled() bool {
+    otherCheck()
+    return false
+}
+
+func call_and_enabled() bool {
+    enabled := otherCheck()
+    return enabled
+}
+
+// `otherCheck()` is never evaluated when the flag is disabled
+func disabled_and_call() bool {
+    return false
+}
+
+// `fallback()` is never evaluated when the flag is enabled
+func enabled_or_fallback() bool {
+    return true
+}
+
+func disabled_or_fallback() bool {
+    x := fallback()
+    return x
+}
+
+// `fallback()` is evaluated before the flag; it has to be preserved
+func fallback_or_enabled() bool {
+    fallback()
+    return true
+}
+
+func assign_call_and_disabled() {
+    var enabled bool
+    otherCheck()
+    enabled = false
+    fmt.Println(enabled)
+}
+
+// The initializer of an `if` statement cannot be split
+func if_initializer_call_and_disabled() bool {
+    if enabled := otherCheck() && false; enabled {
+        return true
+    }
+    return false
+}
+
+// The declaration of several variables is not split
+func multiple_variables_call_and_disabled() (bool, bool) {
+    ready, enabled := isReady(), otherCheck() && false
+    return ready, enabled
+}
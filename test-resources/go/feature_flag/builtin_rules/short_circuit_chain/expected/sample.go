@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+// `cfg.Ready()` is evaluated before the flag; `user.Eligible()` is never evaluated
+func ready_and_disabled_and_eligible() {
+    cfg.Ready()
+    fmt.Println("disabled")
+}
+
+func ready_and_enabled_and_eligible() {
+    if cfg.Ready() && user.Eligible() {
+        fmt.Println("enabled")
+    }
+}
+
+func ready_and_disabled_variable_and_eligible() {
+    cfg.Ready()
+    fmt.Println("done")
+}
+
+func ready_and_enabled_variable_and_eligible() {
+    if cfg.Ready() && user.Eligible() {
+        fmt.Println("enabled")
+    }
+}
+
+// `cfg.Ready()` is evaluated before the flag; `user.Eligible()` is never evaluated
+func ready_or_enabled_or_eligible() {
+    cfg.Ready()
+    fmt.Println("enabled")
+}
+
+func ready_or_disabled_or_eligible() {
+    if cfg.Ready() || user.Eligible() {
+        fmt.Println("enabled")
+    }
+}
+
+func ready_and_disabled_and_eligible_variable() bool {
+    cfg.Ready()
+    return false
+}
+
+// An `else if` statement is replaced with a block
+func else_ready_and_disabled_and_eligible(x bool) {
+    if x {
+        fmt.Println("x")
+    } else {
+        cfg.Ready()
+    }
+}
+
+func else_ready_or_enabled_or_eligible(x bool) {
+    if x {
+        fmt.Println("x")
+    } else {
+        cfg.Ready()
+        fmt.Println("enabled")
+    }
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+var experiments = []string{
+    "otherFlag",
+}
+
+func log_experiments() {
+    for _, f := range experiments {
+        fmt.Println(f)
+    }
+}
+
+func log_other_experiments() {
+    for _, f := range experiments {
+        fmt.Println(f)
+    }
+}
+
+func log_stale_experiment() {
+}
+
+// ranging over a channel of flag names
+func drain_experiments(names chan string) {
+    for name := range names {
+        if name == "staleFlag" {
+            fmt.Println("stale")
+        }
+        fmt.Println(name)
+    }
+}
+
+func log_stale_experiments() {
+}
+
+func log_local_experiments() {
+    flags := []string{"otherFlag"}
+    for _, f := range flags {
+        fmt.Println(f)
+    }
+}
+
+// the parameter is another collection (that may contain the stale flag)
+func log_given_experiments(experiments []string) {
+    for _, f := range experiments {
+        if f == "staleFlag" {
+            fmt.Println("stale")
+        }
+    }
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+const staleFlag = "staleFlag"
+
+var handlers = map[string]func(ctx context.Context){
+    "otherFlag": handleOtherFlag,
+}
+
+// `reportStaleFlag` is still referenced by other.go
+var reporters = map[string]func(){
+    "otherFlag": reportOtherFlag,
+}
+
+var featureSet = map[string]bool{
+    "otherFlag": true,
+}
+
+func handleOtherFlag(ctx context.Context) {
+    fmt.Println("other")
+}
+
+func handleLateFlag(ctx context.Context) {
+    fmt.Println("late")
+}
+
+func register() {
+    handlers["lateFlag"] = handleLateFlag
+}
+
+func dispatch(ctx context.Context, flag string) {
+    handlers[flag](ctx)
+}
+
+func validate() error {
+    return errors.New("unknown flag")
+}
+
+func describe() {
+    fmt.Println("stale disabled")
+    fmt.Println(featureSet["otherFlag"])
+}
+
+func reportStaleFlag() {
+    fmt.Println("stale")
+}
+
+func reportOtherFlag() {
+    fmt.Println("other")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+const staleFlag = "staleFlag"
+
+var handlers = map[string]func(ctx context.Context){
+    staleFlag:   handleStaleFlag,
+    "otherFlag": handleOtherFlag,
+}
+
+// `reportStaleFlag` is still referenced by other.go
+var reporters = map[string]func(){
+    "staleFlag": reportStaleFlag,
+    "otherFlag": reportOtherFlag,
+}
+
+var featureSet = map[string]bool{
+    "staleFlag": true,
+    "otherFlag": true,
+}
+
+func handleStaleFlag(ctx context.Context) {
+    fmt.Println("stale")
+}
+
+func handleOtherFlag(ctx context.Context) {
+    fmt.Println("other")
+}
+
+func handleLateFlag(ctx context.Context) {
+    fmt.Println("late")
+}
+
+func register() {
+    handlers["lateFlag"] = handleLateFlag
+    handlers["staleFlag"] = handleLateFlag
+}
+
+func dispatch(ctx context.Context, flag string) {
+    if handler, ok := handlers[staleFlag]; ok {
+        handler(ctx)
+    }
+    handlers["staleFlag"](ctx)
+    handlers[flag](ctx)
+}
+
+func validate() error {
+    if _, ok := handlers["staleFlag"]; !ok {
+        return errors.New("unknown flag")
+    }
+    return nil
+}
+
+func describe() {
+    if featureSet["staleFlag"] {
+        fmt.Println("stale enabled")
+    } else {
+        fmt.Println("stale disabled")
+    }
+    fmt.Println(featureSet["otherFlag"])
+}
+
+func reportStaleFlag() {
+    fmt.Println("stale")
+}
+
+func reportOtherFlag() {
+    fmt.Println("other")
+}
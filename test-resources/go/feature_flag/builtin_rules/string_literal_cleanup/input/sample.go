@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "fmt"
+    "strings"
+)
+
+func greet() {
+    greeting := exp.StrValue("greeting")
+    if len(greeting) == 0 {
+        fmt.Println("no greeting")
+    } else {
+        fmt.Println(greeting)
+    }
+}
+
+func say_goodbye() {
+    farewell := exp.StrValue("farewell")
+    if farewell != "" {
+        fmt.Println(farewell)
+    }
+    fmt.Println("bye")
+}
+
+func check_prefix() {
+    if strings.HasPrefix(exp.StrValue("farewell"), "bye") {
+        fmt.Println("farewell prefix")
+    }
+    if strings.HasPrefix(exp.StrValue("greeting"), "") {
+        fmt.Println("greeting prefix")
+    }
+}
+
+// the variable is re-assigned, it should not be in-lined
+func reassigned() {
+    greeting := exp.StrValue("greeting")
+    if len(greeting) > 0 {
+        greeting = greeting + "!"
+    }
+    fmt.Println(greeting)
+}
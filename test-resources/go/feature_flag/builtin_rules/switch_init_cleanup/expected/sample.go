@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func switch_init_flag_variable() {
+	fmt.Println("enabled")
+	fmt.Println("after")
+}
+
+func switch_init_flag_variable_tuple() {
+	fmt.Println("disabled")
+}
+
+func switch_init_with_flag_value(f func() int) {
+	{
+		x := f()
+		fmt.Println("enabled", x)
+	}
+	x := "not the init variable"
+	fmt.Println(x)
+}
+
+func switch_init_flag_variable_not_first() {
+	switch {
+	case len(fmt.Sprint()) > 0:
+		fmt.Println("first")
+	default:
+		fmt.Println("enabled")
+	}
+}
+
+// The `switch` already has a `default` clause
+func switch_init_flag_variable_last_with_default() {
+	switch {
+	default:
+		fmt.Println("default")
+	case len(fmt.Sprint()) > 0:
+		fmt.Println("first")
+	case true:
+		fmt.Println("enabled")
+	}
+}
+
+func switch_init_flag_variable_break(items []string) {
+	for _, item := range items {
+		switch {
+		case item == "":
+			break
+		}
+	}
+}
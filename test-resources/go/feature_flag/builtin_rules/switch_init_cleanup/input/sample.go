@@ -0,0 +1,78 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+func switch_init_flag_variable() {
+	switch enabled := exp.BoolValue("true"); {
+	case enabled:
+		fmt.Println("enabled")
+	default:
+		fmt.Println("disabled")
+	}
+	fmt.Println("after")
+}
+
+func switch_init_flag_variable_tuple() {
+	switch enabled, _ := exp.BoolValueE("false"); {
+	case enabled:
+		fmt.Println("enabled")
+	default:
+		fmt.Println("disabled")
+	}
+}
+
+func switch_init_with_flag_value(f func() int) {
+	switch x := f(); exp.BoolValue("true") {
+	case true:
+		fmt.Println("enabled", x)
+	case false:
+		fmt.Println("disabled", x)
+	}
+	x := "not the init variable"
+	fmt.Println(x)
+}
+
+func switch_init_flag_variable_not_first() {
+	switch enabled := exp.BoolValue("true"); {
+	case len(fmt.Sprint()) > 0:
+		fmt.Println("first")
+	case enabled:
+		fmt.Println("enabled")
+	}
+}
+
+// The `switch` already has a `default` clause
+func switch_init_flag_variable_last_with_default() {
+	switch enabled := exp.BoolValue("true"); {
+	default:
+		fmt.Println("default")
+	case len(fmt.Sprint()) > 0:
+		fmt.Println("first")
+	case enabled:
+		fmt.Println("enabled")
+	}
+}
+
+func switch_init_flag_variable_break(items []string) {
+	for _, item := range items {
+		switch enabled := exp.BoolValue("false"); {
+		case enabled:
+			fmt.Println("enabled", item)
+		case item == "":
+			break
+		}
+	}
+}
@@ -71,3 +71,11 @@ func isFlagEnabledFunc() bool {
     fmt.Println("not enabled")
     return false
 }
+
+// the local variable shadows the flag constant
+func shadowed(otherFlag string) {
+    staleFlagConst := otherFlag
+    if exp.BoolValue(staleFlagConst) {
+        fmt.Println("shadowed")
+    }
+}
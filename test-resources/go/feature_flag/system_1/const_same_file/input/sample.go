@@ -96,3 +96,11 @@ func isFlagEnabledFunc() bool {
 
     return isFlagEnabledFunc
 }
+
+// the local variable shadows the flag constant
+func shadowed(otherFlag string) {
+    staleFlagConst := otherFlag
+    if exp.BoolValue(staleFlagConst) {
+        fmt.Println("shadowed")
+    }
+}
@@ -0,0 +1,55 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+const staleFlagConst = "staleFlag"
+
+type Client struct {
+	exp   Experiment
+	check func(string) bool
+}
+
+func NewClient(exp Experiment) *Client {
+	c := &Client{exp: exp}
+	c.check = c.exp.BoolValue
+	return c
+}
+
+func a(exp Experiment) {
+	fn := exp.BoolValue
+	if fn(staleFlagConst) {
+		fmt.Println("enabled")
+	} else {
+		fmt.Println("disabled")
+	}
+}
+
+// the method value is still used, so it should not be deleted
+func b(exp Experiment) {
+	isEnabled := exp.BoolValue
+	if !isEnabled(staleFlagConst) {
+		fmt.Println("disabled")
+	}
+	register(isEnabled)
+}
+
+func (c *Client) c() {
+	if c.check(staleFlagConst) {
+		fmt.Println("enabled")
+	} else {
+		fmt.Println("disabled")
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+const staleFlagConst = "staleFlag"
+
+type Base struct{}
+
+type Client struct {
+    Base
+}
+
+type Server struct{}
+
+// value receiver, declared before the pointer receiver method with the same name and parameters
+func (s Server) run() {
+    enabled := true
+    if enabled {
+        fmt.Println("server enabled")
+    }
+}
+
+// pointer receiver
+func (c *Client) run() {
+    fmt.Println("client disabled")
+}
+
+// value receiver of the embedded type
+func (b Base) run() {
+    fmt.Println("base disabled")
+}
+
+// promoted to `Client` through embedding
+func (b *Base) isReady() bool {
+    return false
+}
+
+func (c *Client) start() {
+    // should not replace the promoted method call
+    if c.isReady() {
+        fmt.Println("ready")
+    }
+}
+
+func (c Client) stop() {
+    fmt.Println("not stopped")
+}
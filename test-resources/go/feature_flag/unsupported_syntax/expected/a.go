@@ -0,0 +1,27 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+
+import "fmt"
+
+// `??` stands for a syntax newer than the bundled grammar
+func withDefault(m map[string]int, k string) int {
+	if exp.BoolValue("true") {
+		return m[k] ?? 0
+	}
+	return 0
+}
+
+func greet() {
+	fmt.Println("new")
+}
@@ -0,0 +1,41 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+const staleFlag = "payments.stale"
+
+// Not a removed flag (the `.` is matched literally)
+const otherFlag = "paymentsXstale"
+
+func removed() bool {
+    return exp.BoolValue("stale_flag")
+}
+
+func removedRaw() bool {
+    return exp.BoolValue(`stale_flag`)
+}
+
+// The name of the flag follows the context
+func removedWithContext(ctx context.Context) bool {
+    return exp.BoolValue(ctx, "stale_flag")
+}
+
+func live() bool {
+    return exp.BoolValue("live_flag")
+}
+
+// Not a call to the flag API
+func notFlagAPI() string {
+    return cfg.StrValue("stale_flag")
+}
@@ -0,0 +1,45 @@
+/*
+Copyright (c) 2023 Uber Technologies, Inc.
+
+ <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ except in compliance with the License. You may obtain a copy of the License at
+ <p>http://www.apache.org/licenses/LICENSE-2.0
+
+ <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ express or implied. See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+    "fmt"
+    "os"
+    "reflect"
+)
+
+func cleanable() bool {
+    return exp.BoolValue("new_flow")
+}
+
+func concatenated(region string) bool {
+    return exp.BoolValue("new_flow_" + region)
+}
+
+func formatted(region string) bool {
+    return exp.BoolValue(fmt.Sprintf("new_flow_%s", region))
+}
+
+func reflection() bool {
+    lookup := reflect.ValueOf(exp).MethodByName("BoolValue")
+    return lookup.Call([]reflect.Value{reflect.ValueOf("new_flow")})[0].Bool()
+}
+
+func from_map(cfg Config) bool {
+    return exp.BoolValue(cfg.Flags["new_flow"])
+}
+
+func from_config() bool {
+    return exp.BoolValue(os.Getenv("NEW_FLOW_FLAG"))
+}
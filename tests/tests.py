@@ -13,8 +13,11 @@
 
 from pathlib import Path
 from polyglot_piranha import Constraint, execute_piranha, PiranhaArguments, PiranhaOutputSummary, Rule, RuleGraph, OutgoingEdges
+from polyglot_piranha import apply_plan, check_piranha, plan_piranha
 from os.path import join, basename
 from os import listdir
+from shutil import copytree
+from tempfile import TemporaryDirectory
 import re
 
 
@@ -137,6 +140,36 @@ import java.util.List;
         "test-resources/java/insert_field_and_initializer/", output_summaries
     )
 
+def test_piranha_check_plan_and_apply():
+    path_to_scenario = "test-resources/java/feature_flag_system_1/control"
+    with TemporaryDirectory() as temp_dir:
+        path_to_codebase = join(temp_dir, "input")
+        copytree(join(path_to_scenario, "input"), path_to_codebase)
+        args = PiranhaArguments(
+            path_to_configurations=join(path_to_scenario, "configurations"),
+            language="java",
+            substitutions={
+                "stale_flag_name": "STALE_FLAG",
+                "treated": "false",
+                "treated_complement": "true",
+                "namespace": "some_long_name",
+            },
+            path_to_codebase=path_to_codebase,
+            cleanup_comments=True,
+            delete_file_if_empty=False,
+        )
+
+        # Neither checking nor planning edits the code base
+        assert check_piranha(args, join(path_to_scenario, "expected")) == ""
+        path_to_plan = join(temp_dir, "plan.json")
+        assert plan_piranha(args, path_to_plan) == 2
+        assert apply_plan(path_to_plan) == []
+
+        for file_name in listdir(join(path_to_scenario, "expected")):
+            expected_content = Path(join(path_to_scenario, "expected", file_name)).read_text()
+            applied_content = Path(join(path_to_codebase, file_name)).read_text()
+            assert "".join(expected_content.split()) == "".join(applied_content.split())
+
 def is_as_expected(path_to_scenario, output_summary):
     expected_output = join(path_to_scenario, "expected")
     input_dir = join(path_to_scenario, "input")